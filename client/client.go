@@ -0,0 +1,151 @@
+// Package client provides a client for the control endpoints of a running duty
+// server, such as one started from the duty docker image, for test suites to
+// reset and set routes between tests.
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gomicro/duty/pkg/control"
+)
+
+const (
+	defaultStatusEndpoint  = "/duty/status"
+	defaultResetEndpoint   = "/duty/reset"
+	defaultSetEndpoint     = "/duty/set"
+	defaultMetricsEndpoint = "/duty/metrics"
+	defaultExportEndpoint  = "/duty/export"
+)
+
+// Client represents a client of the control endpoints of a duty server
+type Client struct {
+	URL        string
+	HTTPClient *http.Client
+	Endpoints  Endpoints
+}
+
+// Endpoints represents the paths of the control endpoints, which may be
+// changed in the duty config
+type Endpoints struct {
+	Status  string
+	Reset   string
+	Set     string
+	Metrics string
+	Export  string
+}
+
+// New returns a client for the duty server at the given base url using the
+// default control endpoints
+func New(baseURL string) *Client {
+	return &Client{
+		URL:        strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+		Endpoints: Endpoints{
+			Status:  defaultStatusEndpoint,
+			Reset:   defaultResetEndpoint,
+			Set:     defaultSetEndpoint,
+			Metrics: defaultMetricsEndpoint,
+			Export:  defaultExportEndpoint,
+		},
+	}
+}
+
+// Status checks that the duty server is functioning
+func (c *Client) Status(ctx context.Context) error {
+	_, err := c.get(ctx, c.Endpoints.Status, nil)
+	return err
+}
+
+// Reset returns every route of the duty server to the response it starts at,
+// given by its start or selected responses, or otherwise its first response
+func (c *Client) Reset(ctx context.Context) error {
+	_, err := c.get(ctx, c.Endpoints.Reset, nil)
+	return err
+}
+
+// Set sets the named variable route to return the response with the given id
+func (c *Client) Set(ctx context.Context, name, id string) error {
+	q := url.Values{}
+	q.Set("name", name)
+	q.Set("id", id)
+
+	_, err := c.get(ctx, c.Endpoints.Set, q)
+	return err
+}
+
+// Metrics returns the metrics of the duty server in the Prometheus text format
+func (c *Client) Metrics(ctx context.Context) (string, error) {
+	b, err := c.get(ctx, c.Endpoints.Metrics, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// Export returns a zip archive of the current state of the duty server, holding
+// a config as duty.yaml alongside its payload files
+func (c *Client) Export(ctx context.Context) ([]byte, error) {
+	return c.get(ctx, c.Endpoints.Export, nil)
+}
+
+func (c *Client) get(ctx context.Context, endpoint string, q url.Values) ([]byte, error) {
+	u := c.URL + endpoint
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err.Error())
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach duty: %v", err.Error())
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err.Error())
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, parseError(endpoint, res, b)
+	}
+
+	return b, nil
+}
+
+// Error represents an error response returned by a duty control endpoint
+type Error struct {
+	Endpoint   string
+	StatusCode int
+	Message    string
+	err        error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("duty %v returned %v: %v", e.Endpoint, e.StatusCode, e.Message)
+}
+
+// Unwrap returns the control error the response reported the code of, if any,
+// so it may be checked with errors.Is
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+func parseError(endpoint string, res *http.Response, body []byte) *Error {
+	return &Error{
+		Endpoint:   endpoint,
+		StatusCode: res.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+		err:        control.FromCode(res.Header.Get(control.ErrorCodeHeader)),
+	}
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/duty/pkg/control"
+	"github.com/gomicro/duty/pkg/duty"
+	. "github.com/onsi/gomega"
+)
+
+func TestClient(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Client", func() {
+		var s *duty.Server
+		var c *Client
+		var ctx context.Context
+
+		g.BeforeEach(func() {
+			s = duty.Config(
+				duty.Route("/v1/ordinal").Name("ord").Ordinal(duty.Respond(200), duty.Respond(503)),
+				duty.Route("/v1/var").Name("var").Variable(
+					duty.Respond(200).ID("ok"),
+					duty.Respond(404).ID("missing"),
+				),
+			).Serve(t)
+
+			c = New(s.URL + "/")
+			ctx = context.Background()
+		})
+
+		g.It("should check the status", func() {
+			Expect(c.Status(ctx)).To(BeNil())
+		})
+
+		g.It("should reset routes", func() {
+			res, err := http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/ordinal"))
+			Expect(err).To(BeNil())
+			res.Body.Close()
+
+			Expect(c.Reset(ctx)).To(BeNil())
+
+			res, err = http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/ordinal"))
+			Expect(err).To(BeNil())
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		g.It("should set routes", func() {
+			Expect(c.Set(ctx, "var", "missing")).To(BeNil())
+
+			res, err := http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/var"))
+			Expect(err).To(BeNil())
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		g.It("should return typed errors", func() {
+			err := c.Set(ctx, "nope", "missing")
+			Expect(errors.Is(err, control.ErrRouteNotFound)).To(BeTrue())
+
+			var e *Error
+			Expect(errors.As(err, &e)).To(BeTrue())
+			Expect(e.StatusCode).To(Equal(http.StatusBadRequest))
+
+			err = c.Set(ctx, "var", "nope")
+			Expect(errors.Is(err, control.ErrIDNotFound)).To(BeTrue())
+
+			err = c.Set(ctx, "ord", "first")
+			Expect(errors.Is(err, control.ErrInvalidRouteType)).To(BeTrue())
+		})
+
+		g.It("should read metrics", func() {
+			m, err := c.Metrics(ctx)
+			Expect(err).To(BeNil())
+			Expect(m).To(ContainSubstring("duty_requests_total"))
+		})
+	})
+}
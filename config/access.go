@@ -0,0 +1,291 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jsonAccessFormat     = "json"
+	combinedAccessFormat = "combined"
+
+	stdoutDestination = "stdout"
+	stderrDestination = "stderr"
+)
+
+type accessKey struct{}
+
+// Logging represents the logging options of Duty. The level sets the threshold
+// of the application log, and access enables a log line per request served.
+type Logging struct {
+	Level  string     `yaml:"level,omitempty"`
+	Access *AccessLog `yaml:"access,omitempty"`
+}
+
+// AccessLog represents the format and destination of the access log. The
+// format may be `json` or `combined` for the Apache combined log format, and
+// the destination may be `stdout`, `stderr`, or the path of a file to append
+// to.
+type AccessLog struct {
+	Format      string `yaml:"format,omitempty"`
+	Destination string `yaml:"destination,omitempty"`
+	writer      io.Writer
+	file        *os.File
+	mu          sync.Mutex
+}
+
+// accessEntry collects the details of a request as it is served
+type accessEntry struct {
+	route      *Route
+	response   string
+	fault      string
+	violations []string
+	unmatched  bool
+}
+
+type accessLine struct {
+	Time       string   `json:"time"`
+	Remote     string   `json:"remote"`
+	Method     string   `json:"method"`
+	Host       string   `json:"host"`
+	Path       string   `json:"path"`
+	Route      string   `json:"route,omitempty"`
+	Endpoint   string   `json:"endpoint,omitempty"`
+	Response   string   `json:"response,omitempty"`
+	Fault      string   `json:"fault,omitempty"`
+	Violations []string `json:"violations,omitempty"`
+	Status     int      `json:"status"`
+	Bytes      int      `json:"bytes"`
+	LatencyMS  float64  `json:"latency_ms"`
+}
+
+// accessRecorder wraps a response writer to capture the status and size of
+// the response written
+type accessRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (a *AccessLog) open() error {
+	err := a.close()
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(a.Destination) {
+	case "", stdoutDestination:
+		a.writer = os.Stdout
+
+	case stderrDestination:
+		a.writer = os.Stderr
+
+	default:
+		f, err := os.OpenFile(a.Destination, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("Failed to open access log: %v", err.Error())
+		}
+
+		a.writer = f
+		a.file = f
+	}
+
+	switch strings.ToLower(a.Format) {
+	case "", jsonAccessFormat, combinedAccessFormat:
+		return nil
+
+	default:
+		return fmt.Errorf("Unknown access log format: %v", a.Format)
+	}
+}
+
+// close closes the file the access log was appending to, if any
+func (a *AccessLog) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+
+	err := a.file.Close()
+	a.file = nil
+	a.writer = ioutil.Discard
+
+	return err
+}
+
+func (a *AccessLog) write(rec *accessRecorder, req *http.Request, entry *accessEntry, start time.Time) {
+	var line string
+
+	switch strings.ToLower(a.Format) {
+	case combinedAccessFormat:
+		line = combinedLine(rec, req, start)
+
+	default:
+		l := accessLine{
+			Time:       start.UTC().Format(time.RFC3339Nano),
+			Remote:     remoteHost(req),
+			Method:     req.Method,
+			Host:       req.Host,
+			Path:       req.URL.Path,
+			Response:   entry.response,
+			Fault:      entry.fault,
+			Violations: entry.violations,
+			Status:     rec.status,
+			Bytes:      rec.bytes,
+			LatencyMS:  float64(time.Since(start)) / float64(time.Millisecond),
+		}
+
+		if entry.route != nil {
+			l.Route = entry.route.Name
+			l.Endpoint = entry.route.Endpoint
+		}
+
+		b, err := json.Marshal(l)
+		if err != nil {
+			log.Errorf("failed to marshal access log: %v", err.Error())
+			return
+		}
+
+		line = string(b)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	fmt.Fprintln(a.writer, line) //nolint:errcheck
+}
+
+func combinedLine(rec *accessRecorder, req *http.Request, start time.Time) string {
+	size := "-"
+	if rec.bytes > 0 {
+		size = fmt.Sprintf("%v", rec.bytes)
+	}
+
+	return fmt.Sprintf("%v - - [%v] \"%v %v %v\" %v %v %q %q",
+		remoteHost(req),
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		req.Method,
+		req.URL.RequestURI(),
+		req.Proto,
+		rec.status,
+		size,
+		req.Referer(),
+		req.UserAgent(),
+	)
+}
+
+func remoteHost(req *http.Request) string {
+	h, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return h
+}
+
+// withAccessEntry returns the request with an access entry attached for the
+// handlers to record the route and response served.
+func withAccessEntry(req *http.Request) (*http.Request, *accessEntry) {
+	entry := &accessEntry{}
+	return req.WithContext(context.WithValue(req.Context(), accessKey{}, entry)), entry
+}
+
+func recordRoute(req *http.Request, r *Route) {
+	entry, ok := req.Context().Value(accessKey{}).(*accessEntry)
+	if ok {
+		entry.route = r
+	}
+}
+
+func recordResponse(req *http.Request, resp *Response) {
+	entry, ok := req.Context().Value(accessKey{}).(*accessEntry)
+	if ok {
+		entry.response = resp.ID
+	}
+}
+
+func recordUnmatched(req *http.Request) {
+	entry, ok := req.Context().Value(accessKey{}).(*accessEntry)
+	if ok {
+		entry.unmatched = true
+	}
+}
+
+// recordFault notes a fault deliberately injected into the response to the
+// request.
+func recordFault(req *http.Request, fault string) {
+	entry, ok := req.Context().Value(accessKey{}).(*accessEntry)
+	if ok {
+		entry.fault = fault
+	}
+}
+
+// abort notes the fault and aborts the response, so the client sees the
+// connection dropped rather than the end of the response
+func abort(req *http.Request, fault string) {
+	recordFault(req, fault)
+	panic(http.ErrAbortHandler)
+}
+
+// recordViolations notes the ways the request failed to satisfy its OpenAPI
+// operation.
+func recordViolations(req *http.Request, violations []string) {
+	entry, ok := req.Context().Value(accessKey{}).(*accessEntry)
+	if ok {
+		entry.violations = violations
+	}
+}
+
+func (rec *accessRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *accessRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+
+	return n, err
+}
+
+// Flush implements the http.Flusher interface when the wrapped writer does
+func (rec *accessRecorder) Flush() {
+	f, ok := rec.ResponseWriter.(http.Flusher)
+	if ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements the http.Hijacker interface when the wrapped writer does
+func (rec *accessRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+
+	conn, rw, err := h.Hijack()
+	if err == nil && rec.status == 0 {
+		rec.status = http.StatusSwitchingProtocols
+	}
+
+	return conn, rw, err
+}
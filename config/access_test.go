@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestAccessLog(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Access Log", func() {
+		var f *File
+		var buf *bytes.Buffer
+		var server *httptest.Server
+
+		g.BeforeEach(func() {
+			f, _ = ParseFromFile()
+			buf = &bytes.Buffer{}
+			f.Log.Access = &AccessLog{writer: buf}
+			server = httptest.NewServer(f)
+		})
+
+		g.AfterEach(func() {
+			server.Close()
+		})
+
+		g.It("should write a json line per request", func() {
+			res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/duty/set?name=var&id=401"))
+			Expect(err).To(BeNil())
+			res.Body.Close()
+			buf.Reset()
+
+			res, err = http.Get(fmt.Sprintf("%v%v", server.URL, "/v1/variable"))
+			Expect(err).To(BeNil())
+			res.Body.Close()
+
+			var l accessLine
+			err = json.Unmarshal(buf.Bytes(), &l)
+			Expect(err).To(BeNil())
+
+			Expect(l.Method).To(Equal("GET"))
+			Expect(l.Path).To(Equal("/v1/variable"))
+			Expect(l.Route).To(Equal("var"))
+			Expect(l.Endpoint).To(Equal("/v1/variable"))
+			Expect(l.Response).To(Equal("401"))
+			Expect(l.Status).To(Equal(401))
+			Expect(l.Bytes).To(BeNumerically(">", 0))
+		})
+
+		g.It("should write a line in the combined format", func() {
+			f.Log.Access.Format = "combined"
+
+			req, err := http.NewRequest("GET", fmt.Sprintf("%v%v", server.URL, "/v1/missing?q=1"), nil)
+			Expect(err).To(BeNil())
+			req.Header.Set("User-Agent", "duty-test")
+
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).To(BeNil())
+			res.Body.Close()
+
+			line := buf.String()
+			Expect(line).To(HavePrefix("127.0.0.1 - - ["))
+			Expect(line).To(ContainSubstring(`"GET /v1/missing?q=1 HTTP/1.1" 404 14 "" "duty-test"`))
+			Expect(strings.Count(line, "\n")).To(Equal(1))
+		})
+
+		g.It("should close the file it appends to", func() {
+			dir, err := ioutil.TempDir("", "duty-access")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			p := filepath.Join(dir, "access.log")
+			f.Log.Access = &AccessLog{Destination: p}
+			Expect(f.Init()).To(Succeed())
+
+			res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/v1/static"))
+			Expect(err).To(BeNil())
+			res.Body.Close()
+
+			Expect(f.Close()).To(Succeed())
+			Expect(f.Log.Access.file).To(BeNil())
+
+			res, err = http.Get(fmt.Sprintf("%v%v", server.URL, "/v1/static"))
+			Expect(err).To(BeNil())
+			res.Body.Close()
+
+			b, err := ioutil.ReadFile(p)
+			Expect(err).To(BeNil())
+			Expect(strings.Count(string(b), "\n")).To(Equal(1))
+		})
+	})
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	autoValidator = "auto"
+)
+
+var (
+	// started is when duty started, used as the modification time of
+	// responses without a payload file
+	started = time.Now().UTC().Truncate(time.Second)
+)
+
+// validators returns the ETag and Last-Modified values of the response for the
+// given body, either as declared or computed when set to auto. Auto ETags are
+// a hash of the body, and auto modification times are those of the payload
+// file, or when duty started for responses without one.
+func (resp *Response) validators(b []byte) (string, time.Time) {
+	var (
+		etag     string
+		modified time.Time
+	)
+
+	switch {
+	case strings.EqualFold(resp.ETag, autoValidator):
+		sum := sha256.Sum256(b)
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	case resp.ETag != "":
+		etag = quoteETag(resp.ETag)
+	}
+
+	switch {
+	case strings.EqualFold(resp.Modified, autoValidator):
+		modified = started
+
+		if resp.Payload != "" && resp.Template == "" && resp.Body == "" {
+			fi, err := os.Stat(resp.Payload)
+			if err == nil {
+				modified = fi.ModTime().UTC().Truncate(time.Second)
+			}
+		}
+
+	case resp.Modified != "":
+		modified, _ = parseDate(resp.Modified)
+	}
+
+	return etag, modified
+}
+
+// precondition sets the validators of the response and evaluates the
+// conditional headers of the request against them, returning the status code
+// to answer with in place of the response when a condition decides it. Only
+// successful responses are conditional.
+func (resp *Response) precondition(w http.ResponseWriter, req *http.Request, b []byte) (int, bool) {
+	etag, modified := resp.validators(b)
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+	}
+
+	if resp.Code < 200 || resp.Code > 299 {
+		return 0, false
+	}
+
+	if im := req.Header.Get("If-Match"); im != "" {
+		if !matchETag(im, etag, false) {
+			return http.StatusPreconditionFailed, true
+		}
+	} else if ius := req.Header.Get("If-Unmodified-Since"); ius != "" && !modified.IsZero() {
+		t, err := http.ParseTime(ius)
+		if err == nil && modified.After(t) {
+			return http.StatusPreconditionFailed, true
+		}
+	}
+
+	safe := req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		if !matchETag(inm, etag, true) {
+			return 0, false
+		}
+
+		if safe {
+			return http.StatusNotModified, true
+		}
+
+		return http.StatusPreconditionFailed, true
+	}
+
+	if ims := req.Header.Get("If-Modified-Since"); ims != "" && safe && !modified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err == nil && !modified.After(t) {
+			return http.StatusNotModified, true
+		}
+	}
+
+	return 0, false
+}
+
+// writePrecondition answers a conditional request with the given status,
+// keeping the validators and other headers of the response but not its body
+func writePrecondition(w http.ResponseWriter, code int) {
+	if code == http.StatusNotModified {
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+	}
+
+	w.WriteHeader(code)
+}
+
+// matchETag reports whether any entity tag of the header value matches the
+// tag, using the weak comparison when weak is set and the strong comparison
+// otherwise. A wildcard matches any tag.
+func matchETag(header, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+
+		if t == "*" {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+
+			continue
+		}
+
+		if !strings.HasPrefix(t, "W/") && !strings.HasPrefix(etag, "W/") && t == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// quoteETag returns the declared entity tag quoted as the header requires,
+// leaving tags that are already quoted or weak as they are
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+
+	return `"` + etag + `"`
+}
+
+// parseDate reads a date given in the format of HTTP headers or as RFC 3339
+func parseDate(v string) (time.Time, error) {
+	t, err := http.ParseTime(v)
+	if err == nil {
+		return t.UTC(), nil
+	}
+
+	t, err = time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", v)
+	}
+
+	return t.UTC().Truncate(time.Second), nil
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/ledger"
+	"github.com/gomicro/penname"
+	. "github.com/onsi/gomega"
+)
+
+const conditionalConfig = `
+routes:
+  - endpoint: "/v1/declared"
+    response:
+      code: 200
+      body: "declared"
+      etag: "v1"
+      lastModified: "Tue, 02 Jan 2024 15:04:05 GMT"
+
+  - endpoint: "/v1/auto"
+    response:
+      code: 200
+      payload: "foo.json"
+      etag: "auto"
+      lastModified: "auto"
+
+  - endpoint: "/v1/weak"
+    response:
+      code: 200
+      etag: 'W/"v2"'
+
+  - endpoint: "/v1/missing"
+    response:
+      code: 404
+      etag: "v1"
+`
+
+func TestConditional(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Conditional Requests", func() {
+		var server *httptest.Server
+
+		g.Before(func() {
+			mw := penname.New()
+			log = ledger.New(mw, ledger.DebugLevel)
+
+			f, err := Parse([]byte(conditionalConfig))
+			Expect(err).To(BeNil())
+
+			server = httptest.NewServer(f)
+		})
+
+		g.After(func() {
+			server.Close()
+		})
+
+		do := func(method, path string, header map[string]string) (*http.Response, string) {
+			req, err := http.NewRequest(method, fmt.Sprintf("%v%v", server.URL, path), nil)
+			Expect(err).To(BeNil())
+
+			for k, v := range header {
+				req.Header.Set(k, v)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+
+			return res, string(b)
+		}
+
+		g.It("should emit declared validators", func() {
+			res, body := do("GET", "/v1/declared", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("declared"))
+			Expect(res.Header.Get("ETag")).To(Equal(`"v1"`))
+			Expect(res.Header.Get("Last-Modified")).To(Equal("Tue, 02 Jan 2024 15:04:05 GMT"))
+		})
+
+		g.It("should compute validators from the payload", func() {
+			res, _ := do("GET", "/v1/auto", nil)
+			Expect(res.Header.Get("ETag")).To(MatchRegexp(`^"[0-9a-f]{32}"$`))
+
+			fi, err := os.Stat("foo.json")
+			Expect(err).To(BeNil())
+			Expect(res.Header.Get("Last-Modified")).To(Equal(fi.ModTime().UTC().Format(http.TimeFormat)))
+
+			again, _ := do("GET", "/v1/auto", nil)
+			Expect(again.Header.Get("ETag")).To(Equal(res.Header.Get("ETag")))
+		})
+
+		g.It("should answer matching If-None-Match with 304", func() {
+			res, body := do("GET", "/v1/declared", map[string]string{"If-None-Match": `"v0", "v1"`})
+			Expect(res.StatusCode).To(Equal(http.StatusNotModified))
+			Expect(body).To(Equal(""))
+			Expect(res.Header.Get("ETag")).To(Equal(`"v1"`))
+
+			res, _ = do("GET", "/v1/weak", map[string]string{"If-None-Match": `"v2"`})
+			Expect(res.StatusCode).To(Equal(http.StatusNotModified))
+
+			res, _ = do("GET", "/v1/declared", map[string]string{"If-None-Match": `"v0"`})
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+			res, _ = do("PUT", "/v1/declared", map[string]string{"If-None-Match": "*"})
+			Expect(res.StatusCode).To(Equal(http.StatusPreconditionFailed))
+		})
+
+		g.It("should answer If-Modified-Since with 304 when unmodified", func() {
+			res, _ := do("GET", "/v1/declared", map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 15:04:05 GMT"})
+			Expect(res.StatusCode).To(Equal(http.StatusNotModified))
+
+			earlier := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+			res, _ = do("GET", "/v1/declared", map[string]string{"If-Modified-Since": earlier})
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+			res, _ = do("GET", "/v1/declared", map[string]string{"If-None-Match": `"v0"`, "If-Modified-Since": "Tue, 02 Jan 2024 15:04:05 GMT"})
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		g.It("should answer mismatched If-Match with 412", func() {
+			res, _ := do("PUT", "/v1/declared", map[string]string{"If-Match": `"v0"`})
+			Expect(res.StatusCode).To(Equal(http.StatusPreconditionFailed))
+
+			res, _ = do("PUT", "/v1/declared", map[string]string{"If-Match": `"v1"`})
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+			res, _ = do("PUT", "/v1/weak", map[string]string{"If-Match": `W/"v2"`})
+			Expect(res.StatusCode).To(Equal(http.StatusPreconditionFailed))
+
+			res, _ = do("PUT", "/v1/declared", map[string]string{"If-Unmodified-Since": "Mon, 01 Jan 2024 00:00:00 GMT"})
+			Expect(res.StatusCode).To(Equal(http.StatusPreconditionFailed))
+		})
+
+		g.It("should not apply conditions to unsuccessful responses", func() {
+			res, _ := do("GET", "/v1/missing", map[string]string{"If-None-Match": `"v1"`})
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		g.It("should refuse invalid modification dates", func() {
+			_, err := Parse([]byte(`
+routes:
+  - endpoint: "/v1/foo"
+    response:
+      code: 200
+      lastModified: "yesterday"
+`))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("routes[0].response.lastModified: lastModified must be auto or a date"))
+		})
+	})
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultCORSMaxAge = 60
+	anyOrigin         = "*"
+)
+
+var (
+	defaultCORS = CORS{
+		Methods: []string{"*"},
+		Headers: []string{"*", "Authorization"},
+	}
+)
+
+// CORS represents the cross origin resource sharing policy applied to a route.
+// When no origins are given any origin is allowed. Echoing the origin returns
+// the request's origin rather than a wildcard for allowed origins, as allowing
+// credentials always does since browsers refuse credentials with a wildcard. A
+// negative max age omits the header entirely, and disabling CORS responds
+// without any CORS headers so browser failures can be reproduced.
+type CORS struct {
+	Disabled       bool     `yaml:"disabled,omitempty"`
+	Origins        []string `yaml:"origins,omitempty"`
+	EchoOrigin     bool     `yaml:"echoOrigin,omitempty"`
+	Methods        []string `yaml:"methods,omitempty"`
+	Headers        []string `yaml:"headers,omitempty"`
+	ExposedHeaders []string `yaml:"exposedHeaders,omitempty"`
+	Credentials    bool     `yaml:"credentials,omitempty"`
+	MaxAge         int      `yaml:"maxAge,omitempty"`
+}
+
+// allowOrigin returns the value for the allow origin header given the origin
+// of the request, and false if the origin is not permitted.
+func (c *CORS) allowOrigin(origin string) (string, bool) {
+	if len(c.Origins) == 0 || contains(c.Origins, anyOrigin) {
+		if (c.EchoOrigin || c.Credentials) && origin != "" {
+			return origin, true
+		}
+
+		return anyOrigin, true
+	}
+
+	if origin != "" && contains(c.Origins, origin) {
+		return origin, true
+	}
+
+	return "", false
+}
+
+// apply sets the CORS headers for an actual, non-preflight, response.
+func (c *CORS) apply(w http.ResponseWriter, req *http.Request) {
+	if c.Disabled {
+		return
+	}
+
+	c.setOrigin(w, req)
+
+	if len(c.ExposedHeaders) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+	}
+}
+
+// preflight responds to an options request with the CORS headers of the policy,
+// leaving them out for origins the policy does not allow.
+func (c *CORS) preflight(w http.ResponseWriter, req *http.Request) {
+	methods := c.Methods
+	if len(methods) == 0 {
+		methods = defaultCORS.Methods
+	}
+
+	headers := c.Headers
+	if len(headers) == 0 {
+		headers = defaultCORS.Headers
+	}
+
+	maxAge := c.MaxAge
+	if maxAge == 0 {
+		maxAge = defaultCORSMaxAge
+	}
+
+	if c.setOrigin(w, req) {
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+
+		if maxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(maxAge))
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, post-check=0, pre-check=0")
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setOrigin sets the origin headers for the origin of the request, reporting
+// whether the origin is allowed.
+func (c *CORS) setOrigin(w http.ResponseWriter, req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+
+	allowed, ok := c.allowOrigin(origin)
+	if !ok {
+		log.Debugf("origin not allowed by cors policy: %v", origin)
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", allowed)
+
+	if allowed != anyOrigin {
+		w.Header().Add("Vary", "Origin")
+	}
+
+	if c.Credentials && allowed != anyOrigin {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	envPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+)
+
+// interpolate expands references to environment variables within the values of
+// the config, written as `${VAR}` or `${VAR:-default}`, where the default is
+// used when the variable is unset or empty. A literal `${` may be written as
+// `$${`. Only scalar values are expanded, leaving keys and comments as written,
+// and plain values are resolved again once expanded so that a variable may
+// give a number or boolean. A problem is returned locating each value
+// referring to an undefined variable with no default.
+func interpolate(root *yaml.Node, name string) []Problem {
+	var problems []Problem
+
+	var walk func(n *yaml.Node, path []interface{})
+	walk = func(n *yaml.Node, path []interface{}) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, path)
+			}
+
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				walk(c, append(append([]interface{}{}, path...), i))
+			}
+
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				walk(n.Content[i+1], append(append([]interface{}{}, path...), n.Content[i].Value))
+			}
+
+		case yaml.ScalarNode:
+			if !strings.Contains(n.Value, "${") {
+				return
+			}
+
+			val, undefined := expand(n.Value)
+			for _, v := range undefined {
+				problems = append(problems, Problem{
+					File:    name,
+					Line:    n.Line,
+					Column:  n.Column,
+					Path:    formatPath(path),
+					Message: fmt.Sprintf("undefined variable %v", v),
+					path:    path,
+				})
+			}
+
+			n.Value = val
+
+			if n.Style == 0 {
+				n.Tag = ""
+				n.Tag = n.ShortTag()
+			}
+		}
+	}
+
+	walk(root, nil)
+
+	return problems
+}
+
+// expand returns the value with its references to environment variables
+// expanded, along with the names of any that are undefined
+func expand(s string) (string, []string) {
+	var (
+		out       strings.Builder
+		undefined []string
+		last      int
+	)
+
+	for _, m := range envPattern.FindAllStringSubmatchIndex(s, -1) {
+		out.WriteString(s[last:m[0]])
+		last = m[1]
+
+		if m[2] < 0 {
+			out.WriteString("${")
+			continue
+		}
+
+		v := s[m[2]:m[3]]
+		val, set := os.LookupEnv(v)
+
+		switch {
+		case m[4] >= 0 && val == "":
+			out.WriteString(s[m[6]:m[7]])
+
+		case set:
+			out.WriteString(val)
+
+		default:
+			undefined = append(undefined, v)
+		}
+	}
+
+	out.WriteString(s[last:])
+
+	return out.String(), undefined
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestInterpolate(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Interpolation", func() {
+		g.AfterEach(func() {
+			os.Unsetenv("DUTY_TEST_HOST")
+			os.Unsetenv("DUTY_TEST_BASE")
+			os.Unsetenv("DUTY_TEST_TOKEN")
+			os.Unsetenv("DUTY_TEST_CODE")
+		})
+
+		g.It("should expand environment variables and defaults", func() {
+			os.Setenv("DUTY_TEST_HOST", "api.example.test")
+			os.Setenv("DUTY_TEST_TOKEN", "abc123")
+
+			c, err := ParseFile("./duty_env.yaml")
+			Expect(err).To(BeNil())
+
+			r := c.Routes[0]
+			Expect(r.Host).To(Equal("api.example.test"))
+			Expect(r.Response.Code).To(Equal(302))
+			Expect(r.Response.Headers["Location"]).To(Equal("http://localhost/v1/home"))
+			Expect(r.Response.Body).To(Equal("token abc123 costs ${PRICE}"))
+		})
+
+		g.It("should prefer a set variable over its default", func() {
+			os.Setenv("DUTY_TEST_HOST", "api.example.test")
+			os.Setenv("DUTY_TEST_BASE", "https://staging.example.test")
+			os.Setenv("DUTY_TEST_TOKEN", "abc123")
+
+			c, err := ParseFile("./duty_env.yaml")
+			Expect(err).To(BeNil())
+			Expect(c.Routes[0].Response.Headers["Location"]).To(Equal("https://staging.example.test/v1/home"))
+		})
+
+		g.It("should resolve plain values once expanded", func() {
+			os.Setenv("DUTY_TEST_HOST", "api.example.test")
+			os.Setenv("DUTY_TEST_TOKEN", "abc123")
+			os.Setenv("DUTY_TEST_CODE", "418")
+
+			c, err := ParseFile("./duty_env.yaml")
+			Expect(err).To(BeNil())
+			Expect(c.Routes[0].Response.Code).To(Equal(418))
+		})
+
+		g.It("should keep values holding quotes and newlines whole", func() {
+			os.Setenv("DUTY_TEST_HOST", "api.example.test")
+			os.Setenv("DUTY_TEST_TOKEN", "a\"b\ncode: 500")
+
+			c, err := ParseFile("./duty_env.yaml")
+			Expect(err).To(BeNil())
+			Expect(c.Routes[0].Response.Code).To(Equal(302))
+			Expect(c.Routes[0].Response.Body).To(Equal("token a\"b\ncode: 500 costs ${PRICE}"))
+		})
+
+		g.It("should locate every undefined variable", func() {
+			err := ValidateFile("./duty_env.yaml")
+			Expect(err).NotTo(BeNil())
+
+			var verr *ValidationError
+			Expect(errors.As(err, &verr)).To(BeTrue())
+
+			var lines []string
+			for _, p := range verr.Problems {
+				lines = append(lines, p.String())
+			}
+
+			Expect(lines).To(Equal([]string{
+				`./duty_env.yaml:5:11: routes[0].host: undefined variable DUTY_TEST_HOST`,
+				`./duty_env.yaml:10:13: routes[0].response.body: undefined variable DUTY_TEST_TOKEN`,
+			}))
+		})
+	})
+}
@@ -0,0 +1,389 @@
+package config
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// SnapshotConfigFile is the name of the config file within a snapshot
+	// archive
+	SnapshotConfigFile = "duty.yaml"
+
+	snapshotPayloadDir = "payloads"
+)
+
+// Snapshot returns a config reproducing the current state of the File, with
+// each route starting at the response it would return next, along with the
+// contents of the payload files it refers to keyed by the paths the snapshot
+// refers to them by. Included files are merged into the snapshot, and payloads
+// outside of the working directory are moved into a payloads directory.
+func (f *File) Snapshot() (*File, map[string][]byte, error) {
+	f.prepare()
+
+	s := &File{
+		NotFound: copyResponse(f.NotFound),
+		CORS:     f.CORS,
+		Log:      f.Log,
+	}
+
+	if f.Status != defaultStatusEndpoint {
+		s.Status = f.Status
+	}
+
+	if f.Reset != defaultResetEndpoint {
+		s.Reset = f.Reset
+	}
+
+	if f.Set != defaultSetEndpoint {
+		s.Set = f.Set
+	}
+
+	if f.Metrics != defaultMetricsEndpoint {
+		s.Metrics = f.Metrics
+	}
+
+	if f.Export != defaultExportEndpoint {
+		s.Export = f.Export
+	}
+
+	for _, r := range f.Routes {
+		s.Routes = append(s.Routes, snapshotRoute(r))
+	}
+
+	for _, h := range f.Hosts {
+		s.Hosts = append(s.Hosts, Host{
+			Host:     h.Host,
+			NotFound: h.NotFound,
+		})
+	}
+
+	if f.OpenAPI != nil {
+		s.OpenAPI = &OpenAPI{
+			Spec:      f.OpenAPI.Spec,
+			Validate:  f.OpenAPI.Validate,
+			Violation: copyResponse(f.OpenAPI.Violation),
+		}
+	}
+
+	if f.GRPC != nil {
+		s.GRPC = &GRPC{
+			Descriptors: append([]string(nil), f.GRPC.Descriptors...),
+		}
+	}
+
+	c := newCollector()
+
+	for _, p := range s.paths() {
+		to, err := c.add(*p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		*p = to
+	}
+
+	return s, c.files, nil
+}
+
+// WriteSnapshot writes a zip archive of the snapshot of the File, holding the
+// config as duty.yaml alongside its payload files.
+func (f *File) WriteSnapshot(w io.Writer) error {
+	s, files, err := f.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	err = enc.Encode(s)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal config: %v", err.Error())
+	}
+
+	err = enc.Close()
+	if err != nil {
+		return fmt.Errorf("Failed to marshal config: %v", err.Error())
+	}
+
+	zw := zip.NewWriter(w)
+
+	err = writeZipFile(zw, SnapshotConfigFile, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	for _, p := range sortedFiles(files) {
+		err = writeZipFile(zw, p, files[p])
+		if err != nil {
+			return err
+		}
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return fmt.Errorf("Failed to write snapshot: %v", err.Error())
+	}
+
+	return nil
+}
+
+func handleExport(w http.ResponseWriter, req *http.Request, f *File) {
+	log.Debug("exporting snapshot")
+
+	var buf bytes.Buffer
+	err := f.WriteSnapshot(&buf)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(fmt.Sprintf("failed to export snapshot: %v", err.Error()))) //nolint:errcheck
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="duty.zip"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes()) //nolint:errcheck
+}
+
+// snapshotRoute returns a copy of the route starting at the response it would
+// return next
+func snapshotRoute(r Route) Route {
+	s := Route{
+		Endpoint:  r.Endpoint,
+		Host:      r.Host,
+		Type:      r.Type,
+		Response:  r.Response,
+		Name:      r.Name,
+		CORS:      r.CORS,
+		Operation: r.Operation,
+		Repeat:    r.Repeat,
+		Cutoff:    r.Cutoff,
+		Socket:    copySocket(r.Socket),
+	}
+
+	if len(r.Events) > 0 {
+		s.Events = make([]Event, len(r.Events))
+		copy(s.Events, r.Events)
+	}
+
+	if len(r.Responses) > 0 {
+		s.Responses = make([]Response, len(r.Responses))
+		copy(s.Responses, r.Responses)
+	}
+
+	switch strings.ToLower(r.Type) {
+	case OrdinalRouteType, VariableRouteType:
+		s.Start = r.index
+
+	case VerbRouteType:
+		for verb, i := range r.selected {
+			id := r.Responses[i].ID
+			if id == "" {
+				continue
+			}
+
+			if s.Selected == nil {
+				s.Selected = make(map[string]string)
+			}
+
+			s.Selected[verb] = id
+		}
+	}
+
+	return s
+}
+
+// responses returns every response of the File that may refer to a payload
+func (f *File) responses() []*Response {
+	var resps []*Response
+
+	for i := range f.Routes {
+		r := &f.Routes[i]
+		resps = append(resps, &r.Response)
+
+		for j := range r.Responses {
+			resps = append(resps, &r.Responses[j])
+		}
+	}
+
+	for i := range f.Hosts {
+		resps = append(resps, &f.Hosts[i].NotFound)
+	}
+
+	if f.NotFound != nil {
+		resps = append(resps, f.NotFound)
+	}
+
+	if f.OpenAPI != nil && f.OpenAPI.Violation != nil {
+		resps = append(resps, f.OpenAPI.Violation)
+	}
+
+	return resps
+}
+
+// paths returns the paths of every file the File refers to, namely payloads, the
+// OpenAPI spec and gRPC descriptors, so they may be rewritten in place
+func (f *File) paths() []*string {
+	var paths []*string
+
+	for _, resp := range f.responses() {
+		if resp.Payload != "" {
+			paths = append(paths, &resp.Payload)
+		}
+	}
+
+	for i := range f.Routes {
+		r := &f.Routes[i]
+
+		for j := range r.Events {
+			if r.Events[j].Payload != "" {
+				paths = append(paths, &r.Events[j].Payload)
+			}
+		}
+
+		for _, fr := range r.Socket.frames() {
+			if fr.Payload != "" {
+				paths = append(paths, &fr.Payload)
+			}
+		}
+	}
+
+	if f.OpenAPI != nil && f.OpenAPI.Spec != "" {
+		paths = append(paths, &f.OpenAPI.Spec)
+	}
+
+	if f.GRPC != nil {
+		for i := range f.GRPC.Descriptors {
+			paths = append(paths, &f.GRPC.Descriptors[i])
+		}
+	}
+
+	return paths
+}
+
+// Rebase joins the directory to the relative paths of the files the File refers
+// to. Paths are read relative to the working directory, so a config written
+// into another directory along with its payloads is rebased onto it to find
+// them.
+func (f *File) Rebase(dir string) {
+	for _, p := range f.paths() {
+		if !filepath.IsAbs(*p) {
+			*p = filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(*p)))
+		}
+	}
+}
+
+// copySocket returns a copy of the script whose frames may be changed without
+// changing those of the script
+func copySocket(s *Socket) *Socket {
+	if s == nil {
+		return nil
+	}
+
+	c := *s
+	c.OnConnect = append([]Frame(nil), s.OnConnect...)
+	c.Replies = append([]Reply(nil), s.Replies...)
+
+	for i := range c.Replies {
+		c.Replies[i].Frames = append([]Frame(nil), c.Replies[i].Frames...)
+	}
+
+	return &c
+}
+
+func copyResponse(resp *Response) *Response {
+	if resp == nil {
+		return nil
+	}
+
+	c := *resp
+
+	return &c
+}
+
+// collector gathers the files referred to by a snapshot, keeping the paths of
+// files within the working directory and moving any others into the payloads
+// directory
+type collector struct {
+	files map[string][]byte
+	paths map[string]string
+}
+
+func newCollector() *collector {
+	return &collector{
+		files: make(map[string][]byte),
+		paths: make(map[string]string),
+	}
+}
+
+// add reads the file at the path, returning the path the snapshot should refer
+// to it by. A path already taken by another file is numbered.
+func (c *collector) add(p string) (string, error) {
+	p = filepath.Clean(p)
+	if to, ok := c.paths[p]; ok {
+		return to, nil
+	}
+
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read %v: %v", p, err.Error())
+	}
+
+	base := filepath.ToSlash(p)
+	if filepath.IsAbs(p) || base == ".." || strings.HasPrefix(base, "../") {
+		base = path.Join(snapshotPayloadDir, filepath.Base(p))
+	}
+
+	to := base
+	ext := path.Ext(base)
+	for i := 2; ; i++ {
+		if _, taken := c.files[to]; !taken {
+			break
+		}
+
+		to = fmt.Sprintf("%v_%v%v", strings.TrimSuffix(base, ext), i, ext)
+	}
+
+	c.paths[p] = to
+	c.files[to] = b
+
+	return to, nil
+}
+
+func writeZipFile(zw *zip.Writer, name string, b []byte) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("Failed to write snapshot: %v", err.Error())
+	}
+
+	_, err = fw.Write(b)
+	if err != nil {
+		return fmt.Errorf("Failed to write snapshot: %v", err.Error())
+	}
+
+	return nil
+}
+
+func sortedFiles(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/ledger"
+	"github.com/gomicro/penname"
+	. "github.com/onsi/gomega"
+)
+
+const exportConfig = `
+routes:
+  - endpoint: "/v1/ordinal"
+    type: "ordinal"
+    responses:
+      - code: 200
+        payload: "foo.json"
+      - code: 401
+        payload: "unauthorized.json"
+      - code: 503
+
+  - endpoint: "/v1/variable"
+    type: "variable"
+    name: "var"
+    responses:
+      - code: 200
+        id: "ok"
+      - code: 404
+        payload: "notfound.json"
+        id: "missing"
+
+  - endpoint: "/v1/verb"
+    type: "verb"
+    name: "verb"
+    responses:
+      - verb: GET
+        code: 200
+        id: "found"
+      - verb: GET
+        code: 404
+        id: "gone"
+      - verb: POST
+        code: 201
+        payload: "newfoo.json"
+`
+
+func TestExport(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Export", func() {
+		var f *File
+		var server *httptest.Server
+
+		g.BeforeEach(func() {
+			mw := penname.New()
+			log = ledger.New(mw, ledger.DebugLevel)
+
+			var err error
+			f, err = Parse([]byte(exportConfig))
+			Expect(err).To(BeNil())
+
+			server = httptest.NewServer(f)
+		})
+
+		g.AfterEach(func() {
+			server.Close()
+		})
+
+		do := func(method, path string) int {
+			req, err := http.NewRequest(method, fmt.Sprintf("%v%v", server.URL, path), nil)
+			Expect(err).To(BeNil())
+
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).To(BeNil())
+			res.Body.Close()
+
+			return res.StatusCode
+		}
+
+		g.It("should snapshot the response each route would return next", func() {
+			do("GET", "/v1/ordinal")
+			Expect(f.SetRoute("var", "missing")).To(BeNil())
+			Expect(f.SetRoute("verb", "gone")).To(BeNil())
+
+			s, files, err := f.Snapshot()
+			Expect(err).To(BeNil())
+
+			Expect(s.Routes[0].Start).To(Equal(1))
+			Expect(s.Routes[1].Start).To(Equal(1))
+			Expect(s.Routes[2].Selected).To(Equal(map[string]string{"GET": "gone"}))
+
+			Expect(len(files)).To(Equal(4))
+			Expect(files).To(HaveKey("foo.json"))
+			Expect(files).To(HaveKey("newfoo.json"))
+		})
+
+		g.It("should serve an archive that restores the snapshot", func() {
+			do("GET", "/v1/ordinal")
+			do("GET", "/v1/ordinal")
+			Expect(f.SetRoute("verb", "gone")).To(BeNil())
+
+			res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/duty/export"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/zip"))
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+
+			zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+			Expect(err).To(BeNil())
+			Expect(zr.File[0].Name).To(Equal(SnapshotConfigFile))
+
+			rc, err := zr.File[0].Open()
+			Expect(err).To(BeNil())
+			conf, err := ioutil.ReadAll(rc)
+			Expect(err).To(BeNil())
+			rc.Close()
+
+			restored, err := Parse(conf)
+			Expect(err).To(BeNil())
+
+			server.Close()
+			server = httptest.NewServer(restored)
+
+			Expect(do("GET", "/v1/ordinal")).To(Equal(503))
+			Expect(do("GET", "/v1/verb")).To(Equal(404))
+			Expect(do("POST", "/v1/verb")).To(Equal(201))
+
+			restored.ResetRoutes()
+			Expect(do("GET", "/v1/verb")).To(Equal(404))
+		})
+
+		g.It("should rebase the relative paths of the files referred to", func() {
+			s, _, err := f.Snapshot()
+			Expect(err).To(BeNil())
+
+			s.Routes[1].Responses[0].Payload = "/abs/ok.json"
+			s.Rebase("out")
+
+			Expect(s.Routes[0].Responses[0].Payload).To(Equal("out/foo.json"))
+			Expect(s.Routes[0].Responses[2].Payload).To(Equal(""))
+			Expect(s.Routes[1].Responses[0].Payload).To(Equal("/abs/ok.json"))
+			Expect(s.Routes[2].Responses[2].Payload).To(Equal("out/newfoo.json"))
+		})
+
+		g.It("should number files that would be collected at the same path", func() {
+			dir, err := ioutil.TempDir("", "duty-export")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			wd, err := os.Getwd()
+			Expect(err).To(BeNil())
+			Expect(os.Chdir(dir)).To(BeNil())
+			defer os.Chdir(wd) //nolint:errcheck
+
+			Expect(os.MkdirAll(filepath.Join("external", "payloads"), 0755)).To(BeNil())
+			Expect(ioutil.WriteFile(filepath.Join("external", "foo.json"), []byte(`"external"`), 0644)).To(BeNil())
+			Expect(ioutil.WriteFile(filepath.Join("external", "payloads", "foo.json"), []byte(`"in tree"`), 0644)).To(BeNil())
+
+			c := newCollector()
+
+			to, err := c.add(filepath.Join(dir, "external", "foo.json"))
+			Expect(err).To(BeNil())
+			Expect(to).To(Equal("payloads/foo.json"))
+
+			Expect(os.Chdir("external")).To(BeNil())
+
+			to, err = c.add("./payloads/foo.json")
+			Expect(err).To(BeNil())
+			Expect(to).To(Equal("payloads/foo_2.json"))
+
+			to, err = c.add("payloads/foo.json")
+			Expect(err).To(BeNil())
+			Expect(to).To(Equal("payloads/foo_2.json"))
+
+			Expect(c.files).To(HaveLen(2))
+			Expect(string(c.files["payloads/foo.json"])).To(Equal(`"external"`))
+			Expect(string(c.files["payloads/foo_2.json"])).To(Equal(`"in tree"`))
+		})
+
+		g.It("should refuse starts and selections beyond the responses of a route", func() {
+			_, err := Parse([]byte(`
+routes:
+  - endpoint: "/v1/ordinal"
+    type: "ordinal"
+    start: 2
+    responses:
+      - code: 200
+      - code: 500
+  - endpoint: "/v1/verb"
+    type: "verb"
+    selected:
+      GET: "missing"
+    responses:
+      - verb: GET
+        code: 200
+  - endpoint: "/v1/static"
+    start: 1
+    response:
+      code: 200
+`))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("routes[0].start: start must be between 0 and 1"))
+			Expect(err.Error()).To(ContainSubstring("routes[1].selected.GET: no GET response has id missing"))
+			Expect(err.Error()).To(ContainSubstring("routes[2].start: start requires an ordinal or variable route"))
+		})
+	})
+}
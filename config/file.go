@@ -2,35 +2,61 @@ package config
 
 import (
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/gomicro/duty/pkg/control"
 	"github.com/gomicro/ledger"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 const (
-	defaultStatusEndpoint = "/duty/status"
-	defaultResetEndpoint  = "/duty/reset"
-	defaultSetEndpoint    = "/duty/set"
-	defaultConfigFile     = "./duty.yaml"
+	defaultStatusEndpoint  = "/duty/status"
+	defaultResetEndpoint   = "/duty/reset"
+	defaultSetEndpoint     = "/duty/set"
+	defaultMetricsEndpoint = "/duty/metrics"
+	defaultExportEndpoint  = "/duty/export"
+	defaultConfigFile      = "./duty.yaml"
 
-	configFileEnv = "DUTY_CONFIG_FILE"
+	catchAllEndpoint = "*"
+
+	configFileEnv   = "DUTY_CONFIG_FILE"
+	configFormatEnv = "DUTY_CONFIG_FORMAT"
 )
 
 var (
 	log *ledger.Ledger
+
+	// ErrRouteNotFound is returned when setting a route that does not exist
+	ErrRouteNotFound = control.ErrRouteNotFound
 )
 
 // File represents all the configurable options of Duty
 type File struct {
-	Routes    []Route           `yaml:"routes"`
-	routesMap map[string]*Route `yaml:"-"`
-	Status    string            `yaml:"status"`
-	Reset     string            `yaml:"reset"`
-	Set       string            `yaml:"set"`
+	Routes    []Route             `yaml:"routes,omitempty"`
+	Hosts     []Host              `yaml:"hosts,omitempty"`
+	NotFound  *Response           `yaml:"notFound,omitempty"`
+	CORS      *CORS               `yaml:"cors,omitempty"`
+	Log       Logging             `yaml:"log,omitempty"`
+	routesMap map[string]*Route   `yaml:"-"`
+	templates map[string][]*Route `yaml:"-"`
+	hosts     []string            `yaml:"-"`
+	Status    string              `yaml:"status,omitempty"`
+	Reset     string              `yaml:"reset,omitempty"`
+	Set       string              `yaml:"set,omitempty"`
+	Metrics   string              `yaml:"metrics,omitempty"`
+	Export    string              `yaml:"export,omitempty"`
+	Include   []string            `yaml:"include,omitempty"`
+	OpenAPI   *OpenAPI            `yaml:"openapi,omitempty"`
+	GRPC      *GRPC               `yaml:"grpc,omitempty"`
+	metrics   *metrics            `yaml:"-"`
+	once      sync.Once           `yaml:"-"`
+	source    string              `yaml:"-"`
+	node      *yaml.Node          `yaml:"-"`
 }
 
 func init() {
@@ -39,8 +65,9 @@ func init() {
 
 // ParseFromFile reads an Duty config file from the file specified in the
 // environment or from the default file location if no environment is specified.
-// A File with the populated values is returned and any errors encountered while
-// trying to read the file.
+// The format of the file may also be given in the environment, otherwise it is
+// detected from the extension. A File with the populated values is returned and
+// any errors encountered while trying to read the file.
 func ParseFromFile() (*File, error) {
 	configFile := os.Getenv(configFileEnv)
 
@@ -48,38 +75,211 @@ func ParseFromFile() (*File, error) {
 		configFile = defaultConfigFile
 	}
 
-	b, err := ioutil.ReadFile(configFile)
+	return ParseFileFormat(configFile, os.Getenv(configFormatEnv))
+}
+
+// ParseFile reads the Duty config file at the given path, along with any files
+// it includes. When the path is a directory every YAML file within it is read
+// and merged. A File with the populated values is returned and any errors
+// encountered while trying to read the files.
+func ParseFile(configFile string) (*File, error) {
+	return ParseFileFormat(configFile, "")
+}
+
+// ParseFileFormat reads the Duty config file at the given path as the given
+// format, which may be yaml, json or toml. The format is detected from the
+// extension of the file when empty. Files it includes, or read from a directory,
+// are always detected from their extensions.
+func ParseFileFormat(configFile, format string) (*File, error) {
+	conf, err := load(configFile, format)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conf.Init()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to read config file: %v", err.Error())
+		return nil, err
 	}
 
-	var conf File
-	err = yaml.Unmarshal(b, &conf)
+	return conf, nil
+}
+
+// Parse reads a Duty config from the given YAML. Any files it includes are
+// found relative to the working directory. A File with the populated values is
+// returned and any errors encountered while trying to parse it.
+func Parse(b []byte) (*File, error) {
+	conf, err := loadBytes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conf.Init()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to unmarshal config file: %v", err.Error())
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// ValidateFile reads and validates the Duty config file or directory at the
+// given path without preparing it to be served. A ValidationError locating
+// every problem found within the files is returned if it is invalid.
+func ValidateFile(configFile string) error {
+	return ValidateFileFormat(configFile, "")
+}
+
+// ValidateFileFormat reads and validates the Duty config file at the given path
+// as the given format, detecting it from the extension when empty.
+func ValidateFileFormat(configFile, format string) error {
+	_, err := load(configFile, format)
+	return err
+}
+
+// Init applies the defaults of any unset options and prepares the routes of the
+// File to be served. The log level of the File is applied to the exported
+// ledger logger as well, so the commands serving it log at the same level.
+// Files built by hand should be initialized before serving, otherwise only
+// their routes are prepared when first served.
+func (f *File) Init() error {
+	if f.Log.Level != "" {
+		level := ledger.ParseLevel(f.Log.Level)
+		log.Threshold(level)
+		ledger.Threshold(level)
+	}
+
+	if f.Log.Access != nil {
+		err := f.Log.Access.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.Status == "" {
+		f.Status = defaultStatusEndpoint
 	}
 
-	if conf.Status == "" {
-		conf.Status = defaultStatusEndpoint
+	if f.Reset == "" {
+		f.Reset = defaultResetEndpoint
 	}
 
-	if conf.Reset == "" {
-		conf.Reset = defaultResetEndpoint
+	if f.Set == "" {
+		f.Set = defaultSetEndpoint
 	}
 
-	if conf.Set == "" {
-		conf.Set = defaultSetEndpoint
+	if f.Metrics == "" {
+		f.Metrics = defaultMetricsEndpoint
 	}
 
-	conf.routesMap = make(map[string]*Route)
-	for i, r := range conf.Routes {
-		conf.routesMap[r.Endpoint] = &conf.Routes[i]
+	if f.Export == "" {
+		f.Export = defaultExportEndpoint
 	}
 
-	return &conf, nil
+	if f.NotFound != nil && f.NotFound.Code == 0 {
+		f.NotFound.Code = http.StatusNotFound
+	}
+
+	for i := range f.Hosts {
+		if f.Hosts[i].NotFound.Code == 0 {
+			f.Hosts[i].NotFound.Code = http.StatusNotFound
+		}
+	}
+
+	if f.OpenAPI != nil {
+		err := f.OpenAPI.load(f.Routes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.GRPC != nil {
+		err := f.GRPC.load(f.Routes)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.mapRoutes()
+
+	return nil
+}
+
+// Close releases the resources held by the File once it is no longer served,
+// closing the file of its access log.
+func (f *File) Close() error {
+	if f.Log.Access == nil {
+		return nil
+	}
+
+	return f.Log.Access.close()
+}
+
+// prepare maps the routes of a File that has not been initialized
+func (f *File) prepare() {
+	f.once.Do(func() {
+		if f.routesMap == nil {
+			f.mapRoutes()
+		}
+	})
+}
+
+func (f *File) mapRoutes() {
+	f.routesMap = make(map[string]*Route)
+	f.templates = make(map[string][]*Route)
+	f.hosts = nil
+
+	seen := make(map[string]bool)
+	for i, r := range f.Routes {
+		f.Routes[i].fileCORS = f.CORS
+		f.Routes[i].Reset()
+		f.routesMap[routeKey(r.Host, r.Endpoint)] = &f.Routes[i]
+
+		h := strings.ToLower(r.Host)
+		if parameterized(r.Endpoint) {
+			f.templates[h] = append(f.templates[h], &f.Routes[i])
+		}
+
+		if h != "" && !seen[h] {
+			seen[h] = true
+			f.hosts = append(f.hosts, h)
+		}
+	}
+
+	sortHosts(f.hosts)
+
+	if f.metrics == nil {
+		f.metrics = newMetrics()
+	}
 }
 
 func (f *File) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.prepare()
+
+	start := time.Now()
+	rec := &accessRecorder{ResponseWriter: w}
+	r, entry := withAccessEntry(r)
+
+	defer func() {
+		aborted := recover()
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		f.metrics.observe(rec, r, entry, time.Since(start))
+
+		if f.Log.Access != nil {
+			f.Log.Access.write(rec, r, entry, start)
+		}
+
+		if aborted != nil {
+			panic(aborted)
+		}
+	}()
+
+	f.serve(rec, r)
+}
+
+func (f *File) serve(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path == f.Status {
 		handleStatus(w, r)
 		return
@@ -95,24 +295,132 @@ func (f *File) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	route, found := f.getRoute(r.URL)
+	if r.URL.Path == f.Metrics {
+		handleMetrics(w, r, f)
+		return
+	}
+
+	if r.URL.Path == f.Export {
+		handleExport(w, r, f)
+		return
+	}
+
+	route, params, found := f.matchRoute(r.Host, r.URL)
 	if !found {
-		log.Errorf("route not found for url path: %v", r.URL)
+		log.Errorf("route not found for host %v and url path: %v", r.Host, r.URL)
+		recordUnmatched(r)
+
+		if f.CORS != nil {
+			f.CORS.apply(w, r)
+		} else {
+			defaultCORS.apply(w, r)
+		}
+
+		h, found := f.getHost(r.Host)
+		if found {
+			h.NotFound.write(w, r)
+			return
+		}
+
+		if f.NotFound != nil {
+			f.NotFound.write(w, r)
+			return
+		}
+
 		w.WriteHeader(http.StatusNotFound)
 		w.Write([]byte("path not found")) //nolint:errcheck
 		return
 	}
 
+	r = withParams(r, params)
+
+	recordRoute(r, route)
+
+	if f.OpenAPI != nil {
+		op, vs := f.OpenAPI.check(route, r)
+		if len(vs) > 0 {
+			f.OpenAPI.reject(w, r, op, vs)
+			return
+		}
+	}
+
 	route.ServeHTTP(w, r)
 }
 
-func (f *File) getRoute(reqURL *url.URL) (*Route, bool) {
-	r, found := f.routesMap[reqURL.Path]
-	if !found {
-		return nil, false
+// getRoute looks up the route for the path among the routes scoped to the
+// most specific matching host first, falling back to routes without a host.
+// Catch-all routes are only considered once no route matches the path.
+func (f *File) getRoute(host string, reqURL *url.URL) (*Route, bool) {
+	r, _, found := f.matchRoute(host, reqURL)
+	return r, found
+}
+
+// matchRoute looks up the route for the path as getRoute does, returning the
+// values of any path parameters of the matched endpoint.
+func (f *File) matchRoute(host string, reqURL *url.URL) (*Route, map[string]string, bool) {
+	r, params, found := f.lookupRoute(hostname(host), reqURL.Path)
+	if found {
+		return r, params, true
+	}
+
+	return f.lookupRoute(hostname(host), catchAllEndpoint)
+}
+
+func (f *File) lookupRoute(name, path string) (*Route, map[string]string, bool) {
+	for _, h := range f.hosts {
+		if !matchHost(h, name) {
+			continue
+		}
+
+		r, params, found := f.hostRoute(h, path)
+		if found {
+			return r, params, true
+		}
+	}
+
+	return f.hostRoute("", path)
+}
+
+// hostRoute returns the route of the host matching the path exactly, or
+// otherwise the parameterized route with the most literal segments matching it.
+func (f *File) hostRoute(host, path string) (*Route, map[string]string, bool) {
+	r, found := f.routesMap[routeKey(host, path)]
+	if found {
+		return r, nil, true
+	}
+
+	var params map[string]string
+	for _, t := range f.templates[host] {
+		p, ok := matchEndpoint(t.Endpoint, path)
+		if !ok {
+			continue
+		}
+
+		if r == nil || literalSegments(t.Endpoint) > literalSegments(r.Endpoint) {
+			r, params = t, p
+		}
+	}
+
+	return r, params, r != nil
+}
+
+// getHost returns the most specific host configuration matching the host.
+func (f *File) getHost(host string) (*Host, bool) {
+	name := hostname(host)
+
+	var match *Host
+	for i := range f.Hosts {
+		h := &f.Hosts[i]
+		if !matchHost(h.Host, name) {
+			continue
+		}
+
+		if match == nil || moreSpecific(h.Host, match.Host) {
+			match = h
+		}
 	}
 
-	return r, true
+	return match, match != nil
 }
 
 func handleStatus(w http.ResponseWriter, req *http.Request) {
@@ -123,9 +431,7 @@ func handleStatus(w http.ResponseWriter, req *http.Request) {
 func handleReset(w http.ResponseWriter, req *http.Request, f *File) {
 	log.Debug("resetting endpoints")
 
-	for k := range f.routesMap {
-		f.routesMap[k].Reset()
-	}
+	f.ResetRoutes()
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -142,20 +448,46 @@ func handleSet(w http.ResponseWriter, req *http.Request, f *File) {
 		return
 	}
 
+	err := f.SetRoute(name, id)
+	if code := control.Code(err); code != "" {
+		w.Header().Set(control.ErrorCodeHeader, code)
+	}
+
+	if err == ErrRouteNotFound {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("no route found")) //nolint:errcheck
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(fmt.Sprintf("failed to set route: %v", err.Error()))) //nolint:errcheck
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ResetRoutes returns every route to the response it starts at, given by its
+// start or selected responses, or otherwise its first response
+func (f *File) ResetRoutes() {
+	f.prepare()
+
+	for k := range f.routesMap {
+		f.routesMap[k].Reset()
+	}
+}
+
+// SetRoute sets the route with the given name to return the response with the
+// given id. ErrRouteNotFound is returned if no route has the name.
+func (f *File) SetRoute(name, id string) error {
+	f.prepare()
+
 	for k := range f.routesMap {
 		if f.routesMap[k].Name == name {
-			err := f.routesMap[k].Set(id)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				w.Write([]byte(fmt.Sprintf("failed to set route: %v", err.Error()))) //nolint:errcheck
-				return
-			}
-
-			w.WriteHeader(http.StatusOK)
-			return
+			return f.routesMap[k].Set(id)
 		}
 	}
 
-	w.WriteHeader(http.StatusBadRequest)
-	w.Write([]byte("no route found")) //nolint:errcheck
+	return ErrRouteNotFound
 }
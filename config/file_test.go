@@ -92,6 +92,25 @@ func TestConfig(t *testing.T) {
 				Expect(err).To(BeNil())
 				Expect(string(b)).To(Equal("duty is functioning"))
 			})
+
+			g.It("should serve the routes of a file built by hand", func() {
+				f := &File{
+					Routes: []Route{
+						{Endpoint: "/v1/hand", Response: Response{Code: 202, Body: "built by hand"}},
+					},
+				}
+				server := httptest.NewServer(f)
+				defer server.Close()
+
+				res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/v1/hand"))
+				Expect(err).To(BeNil())
+				defer res.Body.Close()
+
+				b, err := ioutil.ReadAll(res.Body)
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(202))
+				Expect(string(b)).To(Equal("built by hand"))
+			})
 		})
 
 		g.Describe("Routes", func() {
@@ -99,7 +118,7 @@ func TestConfig(t *testing.T) {
 				f, _ := ParseFromFile()
 				u, _ := url.Parse("http://localhost:4567/v1/foo")
 
-				r, found := f.getRoute(u)
+				r, found := f.getRoute(u.Host, u)
 				Expect(found).To(BeTrue())
 				Expect(r.Endpoint).To(Equal("/v1/foo"))
 			})
@@ -108,10 +127,208 @@ func TestConfig(t *testing.T) {
 				f, _ := ParseFromFile()
 				u, _ := url.Parse("http://localhost:4567/v1/notanendpoint")
 
-				r, found := f.getRoute(u)
+				r, found := f.getRoute(u.Host, u)
 				Expect(found).To(BeFalse())
 				Expect(r).To(BeNil())
 			})
 		})
+
+		g.Describe("Path Parameters", func() {
+			var f *File
+			var server *httptest.Server
+
+			g.BeforeEach(func() {
+				var err error
+				f, err = ParseFile("./duty_params.yaml")
+				Expect(err).To(BeNil())
+
+				server = httptest.NewServer(f)
+			})
+
+			g.AfterEach(func() {
+				server.Close()
+			})
+
+			g.It("should prefer an exact endpoint over a parameterized one", func() {
+				u, _ := url.Parse("http://localhost:4567/v1/users/me")
+
+				r, found := f.getRoute(u.Host, u)
+				Expect(found).To(BeTrue())
+				Expect(r.Endpoint).To(Equal("/v1/users/me"))
+
+				u, _ = url.Parse("http://localhost:4567/v1/users/42/orders/7")
+
+				r, found = f.getRoute(u.Host, u)
+				Expect(found).To(BeTrue())
+				Expect(r.Endpoint).To(Equal("/v1/users/{id}/orders/{order}"))
+
+				u, _ = url.Parse("http://localhost:4567/v1/users/42/orders")
+
+				_, found = f.getRoute(u.Host, u)
+				Expect(found).To(BeFalse())
+			})
+
+			g.It("should render path parameters in templates", func() {
+				res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/v1/users/42"))
+				Expect(err).To(BeNil())
+				defer res.Body.Close()
+
+				b, err := ioutil.ReadAll(res.Body)
+				Expect(err).To(BeNil())
+				Expect(string(b)).To(Equal(`{"id": "42"}`))
+			})
+
+			g.It("should set the response of a verb route by id", func() {
+				u := fmt.Sprintf("%v%v", server.URL, "/v1/users/42/orders/7")
+
+				res, err := http.Get(u)
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(200))
+
+				Expect(f.SetRoute("orders", "get-404")).To(BeNil())
+
+				res, err = http.Get(u)
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(404))
+
+				req, _ := http.NewRequest("DELETE", u, nil)
+				res, err = http.DefaultClient.Do(req)
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(204))
+
+				f.ResetRoutes()
+
+				res, err = http.Get(u)
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(200))
+			})
+		})
+
+		g.Describe("Not Found", func() {
+			var server *httptest.Server
+
+			g.BeforeEach(func() {
+				os.Setenv("DUTY_CONFIG_FILE", "./duty_fallback.yaml")
+				defer os.Unsetenv("DUTY_CONFIG_FILE")
+
+				f, _ := ParseFromFile()
+				server = httptest.NewServer(f)
+			})
+
+			g.AfterEach(func() {
+				server.Close()
+			})
+
+			g.It("should serve the configured not found response", func() {
+				res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/v1/missing"))
+				Expect(err).To(BeNil())
+				defer res.Body.Close()
+
+				b, err := ioutil.ReadAll(res.Body)
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+				Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+				Expect(string(b)).To(Equal(`{"error": "no route for GET /v1/missing"}`))
+			})
+
+			g.It("should evaluate a catch-all route last", func() {
+				req, err := http.NewRequest("GET", fmt.Sprintf("%v%v", server.URL, "/v1/missing"), nil)
+				Expect(err).To(BeNil())
+				req.Host = "api.fallback.test"
+
+				res, err := http.DefaultClient.Do(req)
+				Expect(err).To(BeNil())
+				defer res.Body.Close()
+
+				Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+
+				req.URL.Path = "/v1/foo"
+
+				res, err = http.DefaultClient.Do(req)
+				Expect(err).To(BeNil())
+				defer res.Body.Close()
+
+				Expect(res.StatusCode).To(Equal(http.StatusOK))
+			})
+		})
+
+		g.Describe("Hosts", func() {
+			var f *File
+			var server *httptest.Server
+
+			g.BeforeEach(func() {
+				os.Setenv("DUTY_CONFIG_FILE", "./duty_hosts.yaml")
+				defer os.Unsetenv("DUTY_CONFIG_FILE")
+
+				f, _ = ParseFromFile()
+				server = httptest.NewServer(f)
+			})
+
+			g.AfterEach(func() {
+				server.Close()
+			})
+
+			g.It("should prefer an exact host over a wildcard or no host", func() {
+				u, _ := url.Parse("http://api.example.test:4567/v1/foo")
+
+				r, found := f.getRoute(u.Host, u)
+				Expect(found).To(BeTrue())
+				Expect(r.Response.Code).To(Equal(201))
+
+				u, _ = url.Parse("http://other.example.test/v1/foo")
+
+				r, found = f.getRoute(u.Host, u)
+				Expect(found).To(BeTrue())
+				Expect(r.Response.Code).To(Equal(202))
+
+				u, _ = url.Parse("http://localhost:4567/v1/foo")
+
+				r, found = f.getRoute(u.Host, u)
+				Expect(found).To(BeTrue())
+				Expect(r.Response.Code).To(Equal(200))
+			})
+
+			g.It("should not match a wildcard against the bare domain", func() {
+				u, _ := url.Parse("http://example.test/v1/foo")
+
+				r, found := f.getRoute(u.Host, u)
+				Expect(found).To(BeTrue())
+				Expect(r.Response.Code).To(Equal(200))
+			})
+
+			g.It("should serve the not found response of the matching host", func() {
+				req, err := http.NewRequest("GET", fmt.Sprintf("%v%v", server.URL, "/v1/missing"), nil)
+				Expect(err).To(BeNil())
+				req.Host = "api.example.test"
+
+				res, err := http.DefaultClient.Do(req)
+				Expect(err).To(BeNil())
+				defer res.Body.Close()
+
+				b, err := ioutil.ReadAll(res.Body)
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+				Expect(string(b)).To(ContainSubstring("not found"))
+
+				req.Host = "www.example.test"
+
+				res, err = http.DefaultClient.Do(req)
+				Expect(err).To(BeNil())
+				defer res.Body.Close()
+
+				Expect(res.StatusCode).To(Equal(http.StatusTeapot))
+
+				req.Host = "localhost"
+
+				res, err = http.DefaultClient.Do(req)
+				Expect(err).To(BeNil())
+				defer res.Body.Close()
+
+				b, err = ioutil.ReadAll(res.Body)
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+				Expect(string(b)).To(Equal("path not found"))
+			})
+		})
 	})
 }
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestFormats(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Config Formats", func() {
+		var expected *File
+
+		g.Before(func() {
+			var err error
+			expected, err = ParseFile("./duty_hosts.yaml")
+			Expect(err).To(BeNil())
+		})
+
+		for _, file := range []string{"./duty_hosts.json", "./duty_hosts.toml"} {
+			file := file
+
+			g.It("should parse "+file+" the same as its yaml", func() {
+				c, err := ParseFile(file)
+				Expect(err).To(BeNil())
+
+				Expect(len(c.Routes)).To(Equal(len(expected.Routes)))
+				for i, r := range c.Routes {
+					Expect(r.Endpoint).To(Equal(expected.Routes[i].Endpoint))
+					Expect(r.Host).To(Equal(expected.Routes[i].Host))
+					Expect(r.Response).To(Equal(expected.Routes[i].Response))
+					Expect(r.Source).To(Equal(file))
+				}
+
+				Expect(len(c.Hosts)).To(Equal(len(expected.Hosts)))
+				for i, h := range c.Hosts {
+					Expect(h.Host).To(Equal(expected.Hosts[i].Host))
+					Expect(h.NotFound).To(Equal(expected.Hosts[i].NotFound))
+				}
+			})
+		}
+
+		g.It("should parse a format given in the environment", func() {
+			os.Setenv("DUTY_CONFIG_FILE", "./duty_hosts.toml")
+			os.Setenv("DUTY_CONFIG_FORMAT", "json")
+			defer os.Unsetenv("DUTY_CONFIG_FILE")
+			defer os.Unsetenv("DUTY_CONFIG_FORMAT")
+
+			_, err := ParseFromFile()
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("Failed to unmarshal config file ./duty_hosts.toml"))
+		})
+
+		g.It("should return an error for an unknown format", func() {
+			_, err := ParseFileFormat("./duty_hosts.yaml", "xml")
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring(`unknown config format "xml"`))
+		})
+
+		g.It("should report problems in toml files by path", func() {
+			err := ValidateFile("./duty_invalid.toml")
+			Expect(err).NotTo(BeNil())
+
+			var verr *ValidationError
+			Expect(errors.As(err, &verr)).To(BeTrue())
+			Expect(verr.Problems).To(HaveLen(1))
+			Expect(verr.Problems[0].String()).To(Equal(`./duty_invalid.toml: routes[0].type: unknown route type "ordnial"`))
+		})
+	})
+}
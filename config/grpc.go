@@ -0,0 +1,364 @@
+package config
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	grpcContentType = "application/grpc"
+
+	grpcUnknown  = 2
+	grpcInternal = 13
+)
+
+// grpcCodes are the names of the gRPC status codes by their value
+var grpcCodes = []string{
+	"OK",
+	"CANCELLED",
+	"UNKNOWN",
+	"INVALID_ARGUMENT",
+	"DEADLINE_EXCEEDED",
+	"NOT_FOUND",
+	"ALREADY_EXISTS",
+	"PERMISSION_DENIED",
+	"RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION",
+	"ABORTED",
+	"OUT_OF_RANGE",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// GRPC represents the protobuf descriptors of the gRPC services mocked by the
+// routes. Descriptors are either .proto sources, whose imports are found
+// alongside them, or FileDescriptorSets, as written by `protoc
+// --include_imports -o` or `buf build -o`. Routes with an endpoint of the form
+// /package.Service/Method serve that method with their gRPC responses.
+type GRPC struct {
+	Descriptors []string `yaml:"descriptors,omitempty"`
+	files       *protoregistry.Files
+}
+
+// GRPCResponse represents the reply to a gRPC call. Messages are written as
+// JSON and encoded as the output type of the method, with unary methods
+// replying with at most one and server-streaming methods with each in turn,
+// waiting for the delay between them. The call ends with the status, given by
+// name or value, along with its message and any further trailers.
+type GRPCResponse struct {
+	Messages []string          `yaml:"messages,omitempty"`
+	Delay    string            `yaml:"delay,omitempty"`
+	Status   string            `yaml:"status,omitempty"`
+	Message  string            `yaml:"message,omitempty"`
+	Trailers map[string]string `yaml:"trailers,omitempty"`
+	frames   [][]byte
+}
+
+// load reads the descriptor sets and binds the gRPC responses of each route
+// to the method named by its endpoint, encoding their messages
+func (g *GRPC) load(routes []Route) error {
+	files, err := g.readDescriptors()
+	if err != nil {
+		return err
+	}
+
+	g.files = files
+
+	for i := range routes {
+		r := &routes[i]
+
+		resps := grpcResponses(r)
+		if len(resps) == 0 {
+			continue
+		}
+
+		md, err := g.method(r.Endpoint)
+		if err != nil {
+			return fmt.Errorf("route %v: %v", r.Endpoint, err.Error())
+		}
+
+		for _, resp := range resps {
+			err := resp.GRPC.encode(md)
+			if err != nil {
+				return fmt.Errorf("route %v: %v", r.Endpoint, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func (g *GRPC) readDescriptors() (*protoregistry.Files, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+
+	add := func(files ...*descriptorpb.FileDescriptorProto) {
+		for _, f := range files {
+			if !seen[f.GetName()] {
+				seen[f.GetName()] = true
+				set.File = append(set.File, f)
+			}
+		}
+	}
+
+	for _, p := range g.Descriptors {
+		if strings.HasSuffix(p, ".proto") {
+			files, err := parseProto(p)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read gRPC descriptors: %v", err.Error())
+			}
+
+			add(files...)
+			continue
+		}
+
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read gRPC descriptors: %v", err.Error())
+		}
+
+		s := &descriptorpb.FileDescriptorSet{}
+		err = proto.Unmarshal(b, s)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read gRPC descriptors %v: %v", p, err.Error())
+		}
+
+		add(s.File...)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read gRPC descriptors: %v", err.Error())
+	}
+
+	return files, nil
+}
+
+// parseProto parses the .proto source at the path, resolving its imports
+// relative to its directory, and returns its descriptor preceded by those of
+// the files it imports
+func parseProto(path string) ([]*descriptorpb.FileDescriptorProto, error) {
+	parser := protoparse.Parser{
+		ImportPaths: []string{filepath.Dir(path)},
+	}
+
+	fds, err := parser.ParseFiles(filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		files []*descriptorpb.FileDescriptorProto
+		walk  func(fd *desc.FileDescriptor)
+	)
+
+	walk = func(fd *desc.FileDescriptor) {
+		for _, dep := range fd.GetDependencies() {
+			walk(dep)
+		}
+
+		files = append(files, fd.AsFileDescriptorProto())
+	}
+
+	for _, fd := range fds {
+		walk(fd)
+	}
+
+	return files, nil
+}
+
+// method returns the descriptor of the method served at the endpoint
+func (g *GRPC) method(endpoint string) (protoreflect.MethodDescriptor, error) {
+	i := strings.LastIndex(endpoint, "/")
+	if i < 0 {
+		return nil, fmt.Errorf("endpoint is not a gRPC method")
+	}
+
+	service, name := strings.TrimPrefix(endpoint[:i], "/"), endpoint[i+1:]
+
+	d, err := g.files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("no service %v found in the gRPC descriptors", service)
+	}
+
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a service", service)
+	}
+
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("service %v has no method %v", service, name)
+	}
+
+	if md.IsStreamingClient() {
+		return nil, fmt.Errorf("method %v streams requests, only unary and server-streaming methods are served", md.FullName())
+	}
+
+	return md, nil
+}
+
+// grpcResponses returns the responses of the route replying to gRPC calls
+func grpcResponses(r *Route) []*Response {
+	var resps []*Response
+
+	if r.Response.GRPC != nil {
+		resps = append(resps, &r.Response)
+	}
+
+	for i := range r.Responses {
+		if r.Responses[i].GRPC != nil {
+			resps = append(resps, &r.Responses[i])
+		}
+	}
+
+	return resps
+}
+
+// encode encodes the messages of the response as the output type of the
+// method
+func (g *GRPCResponse) encode(md protoreflect.MethodDescriptor) error {
+	if !md.IsStreamingServer() && len(g.Messages) > 1 {
+		return fmt.Errorf("unary method %v replies with at most one message", md.FullName())
+	}
+
+	g.frames = nil
+	for i, m := range g.Messages {
+		msg := dynamicpb.NewMessage(md.Output())
+
+		err := protojson.Unmarshal([]byte(m), msg)
+		if err != nil {
+			return fmt.Errorf("messages[%v] is not a valid %v: %v", i, md.Output().FullName(), err.Error())
+		}
+
+		b, err := proto.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to encode messages[%v]: %v", i, err.Error())
+		}
+
+		g.frames = append(g.frames, b)
+	}
+
+	return nil
+}
+
+// write replies to the gRPC call with the messages of the response, each
+// length prefixed and flushed as it is written, ending the call with its
+// status and trailers. The response headers are sent as the initial metadata.
+func (g *GRPCResponse) write(w http.ResponseWriter, req *http.Request, headers map[string]string) {
+	if req.Body != nil {
+		io.Copy(ioutil.Discard, req.Body) //nolint:errcheck
+	}
+
+	code, _ := grpcCode(g.Status)
+	msg := g.Message
+
+	if g.frames == nil && len(g.Messages) > 0 {
+		code, msg = grpcInternal, "duty: gRPC descriptors were not loaded for this method"
+	}
+
+	for k, v := range headers {
+		w.Header().Set(k, v)
+	}
+
+	w.Header().Set("Content-Type", grpcContentType)
+	w.WriteHeader(http.StatusOK)
+
+	f, _ := w.(http.Flusher)
+
+	var delay time.Duration
+	if g.Delay != "" {
+		delay, _ = time.ParseDuration(g.Delay)
+	}
+
+	for i, b := range g.frames {
+		if i > 0 && delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-req.Context().Done():
+				return
+			}
+		}
+
+		prefix := make([]byte, 5)
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(b)))
+
+		_, err := w.Write(append(prefix, b...))
+		if err != nil {
+			return
+		}
+
+		if f != nil {
+			f.Flush()
+		}
+	}
+
+	for k, v := range g.Trailers {
+		w.Header().Set(http.TrailerPrefix+k, v)
+	}
+
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+
+	if msg != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", encodeGRPCMessage(msg))
+	}
+}
+
+// grpcCode returns the value of the status code given by name or value,
+// defaulting to OK
+func grpcCode(status string) (int, bool) {
+	if status == "" {
+		return 0, true
+	}
+
+	n, err := strconv.Atoi(status)
+	if err == nil {
+		return n, n >= 0 && n < len(grpcCodes)
+	}
+
+	for i, name := range grpcCodes {
+		if strings.EqualFold(name, status) {
+			return i, true
+		}
+	}
+
+	return grpcUnknown, false
+}
+
+// encodeGRPCMessage percent encodes the status message as the gRPC protocol
+// requires of the grpc-message trailer
+func encodeGRPCMessage(msg string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,306 @@
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/ledger"
+	"github.com/gomicro/penname"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const grpcConfig = `
+grpc:
+  descriptors:
+    - "orders.protoset"
+
+routes:
+  - endpoint: "/duty.orders.v1.Orders/GetOrder"
+    type: "variable"
+    name: "get_order"
+    responses:
+      - id: "found"
+        headers:
+          X-Request-Id: "abc123"
+        grpc:
+          messages:
+            - '{"id": "42", "state": "SHIPPED", "total": "1250"}'
+      - id: "missing"
+        grpc:
+          status: "NOT_FOUND"
+          message: "no such order: 42%"
+          trailers:
+            X-Retry-After: "30"
+
+  - endpoint: "/duty.orders.v1.Orders/WatchOrders"
+    type: "ordinal"
+    responses:
+      - grpc:
+          delay: "5ms"
+          messages:
+            - '{"id": "1", "state": "PLACED"}'
+            - '{"id": "1", "state": "PACKED"}'
+            - '{"id": "1", "state": "SHIPPED"}'
+      - grpc:
+          status: "14"
+          message: "try again later"
+`
+
+func TestGRPC(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("gRPC Routes", func() {
+		var f *File
+		var server *httptest.Server
+
+		g.Before(func() {
+			mw := penname.New()
+			log = ledger.New(mw, ledger.DebugLevel)
+
+			var err error
+			f, err = Parse([]byte(grpcConfig))
+			Expect(err).To(BeNil())
+
+			server = httptest.NewUnstartedServer(f)
+			server.EnableHTTP2 = true
+			server.StartTLS()
+		})
+
+		g.After(func() {
+			server.Close()
+		})
+
+		call := func(method string) (*http.Response, []protoreflect.Message) {
+			req, err := http.NewRequest("POST", fmt.Sprintf("%v/duty.orders.v1.Orders/%v", server.URL, method), bytes.NewReader(make([]byte, 5)))
+			Expect(err).To(BeNil())
+
+			req.Header.Set("Content-Type", "application/grpc")
+			req.Header.Set("TE", "trailers")
+
+			res, err := server.Client().Do(req)
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+
+			md, err := f.GRPC.method("/duty.orders.v1.Orders/" + method)
+			Expect(err).To(BeNil())
+
+			var msgs []protoreflect.Message
+			for len(b) > 0 {
+				Expect(len(b)).To(BeNumerically(">=", 5))
+				n := int(binary.BigEndian.Uint32(b[1:5]))
+
+				msg := dynamicpb.NewMessage(md.Output())
+				Expect(proto.Unmarshal(b[5:5+n], msg)).To(Succeed())
+
+				msgs = append(msgs, msg)
+				b = b[5+n:]
+			}
+
+			return res, msgs
+		}
+
+		field := func(msg protoreflect.Message, name string) interface{} {
+			return msg.Get(msg.Descriptor().Fields().ByName(protoreflect.Name(name))).Interface()
+		}
+
+		g.It("should reply to unary calls with the message encoded", func() {
+			res, msgs := call("GetOrder")
+
+			Expect(res.ProtoMajor).To(Equal(2))
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/grpc"))
+			Expect(res.Header.Get("X-Request-Id")).To(Equal("abc123"))
+			Expect(res.Trailer.Get("Grpc-Status")).To(Equal("0"))
+
+			Expect(msgs).To(HaveLen(1))
+			Expect(field(msgs[0], "id")).To(Equal("42"))
+			Expect(field(msgs[0], "state")).To(Equal("SHIPPED"))
+			Expect(field(msgs[0], "total")).To(Equal(int64(1250)))
+		})
+
+		g.It("should end calls with the status and trailers of the response set", func() {
+			Expect(f.SetRoute("get_order", "missing")).To(Succeed())
+			defer f.ResetRoutes()
+
+			res, msgs := call("GetOrder")
+
+			Expect(msgs).To(BeEmpty())
+			Expect(res.Trailer.Get("Grpc-Status")).To(Equal("5"))
+			Expect(res.Trailer.Get("Grpc-Message")).To(Equal("no such order: 42%25"))
+			Expect(res.Trailer.Get("X-Retry-After")).To(Equal("30"))
+		})
+
+		g.It("should stream each message of server-streaming calls in turn", func() {
+			defer f.ResetRoutes()
+
+			res, msgs := call("WatchOrders")
+
+			Expect(res.Trailer.Get("Grpc-Status")).To(Equal("0"))
+			Expect(msgs).To(HaveLen(3))
+			Expect(field(msgs[0], "state")).To(Equal("PLACED"))
+			Expect(field(msgs[2], "state")).To(Equal("SHIPPED"))
+
+			res, msgs = call("WatchOrders")
+
+			Expect(msgs).To(BeEmpty())
+			Expect(res.Trailer.Get("Grpc-Status")).To(Equal("14"))
+			Expect(res.Trailer.Get("Grpc-Message")).To(Equal("try again later"))
+		})
+
+		g.It("should answer calls over HTTP/2 without TLS", func() {
+			plain := httptest.NewServer(h2c.NewHandler(f, &http2.Server{}))
+			defer plain.Close()
+
+			client := &http.Client{Transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+					return net.Dial(network, addr)
+				},
+			}}
+
+			req, err := http.NewRequest("POST", fmt.Sprintf("%v/duty.orders.v1.Orders/GetOrder", plain.URL), bytes.NewReader(make([]byte, 5)))
+			Expect(err).To(BeNil())
+			req.Header.Set("Content-Type", "application/grpc")
+
+			res, err := client.Do(req)
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			_, err = ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+
+			Expect(res.ProtoMajor).To(Equal(2))
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/grpc"))
+			Expect(res.Trailer.Get("Grpc-Status")).To(Equal("0"))
+		})
+
+		g.It("should snapshot the descriptors along with the routes", func() {
+			s, files, err := f.Snapshot()
+			Expect(err).To(BeNil())
+
+			Expect(s.GRPC.Descriptors).To(Equal([]string{"orders.protoset"}))
+			Expect(files).To(HaveKey("orders.protoset"))
+			Expect(s.Routes[0].Responses[1].GRPC.Status).To(Equal("NOT_FOUND"))
+		})
+
+		g.Describe("Loading", func() {
+			load := func(endpoint, message string) error {
+				_, err := Parse([]byte(fmt.Sprintf(`
+grpc:
+  descriptors:
+    - "orders.protoset"
+
+routes:
+  - endpoint: %q
+    response:
+      grpc:
+        messages:
+          - %q
+`, endpoint, message)))
+
+				return err
+			}
+
+			g.It("should reject messages not matching the output of the method", func() {
+				err := load("/duty.orders.v1.Orders/GetOrder", `{"sku": "abc"}`)
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(ContainSubstring("messages[0] is not a valid duty.orders.v1.Order"))
+			})
+
+			g.It("should reject methods missing from the descriptors", func() {
+				err := load("/duty.orders.v1.Orders/CancelOrder", `{}`)
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(ContainSubstring("service duty.orders.v1.Orders has no method CancelOrder"))
+
+				err = load("/duty.orders.v1.Carts/GetCart", `{}`)
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(ContainSubstring("no service duty.orders.v1.Carts found"))
+			})
+
+			g.It("should reject client-streaming methods", func() {
+				err := load("/duty.orders.v1.Orders/ImportOrders", `{"imported": 1}`)
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(ContainSubstring("only unary and server-streaming methods are served"))
+			})
+
+			g.It("should parse .proto sources", func() {
+				f, err := Parse([]byte(`
+grpc:
+  descriptors:
+    - "orders.proto"
+
+routes:
+  - endpoint: "/duty.orders.v1.Orders/GetOrder"
+    response:
+      grpc:
+        messages:
+          - '{"id": "42", "total": "1250"}'
+`))
+				Expect(err).To(BeNil())
+
+				md, err := f.GRPC.method("/duty.orders.v1.Orders/WatchOrders")
+				Expect(err).To(BeNil())
+				Expect(string(md.Output().FullName())).To(Equal("duty.orders.v1.Order"))
+				Expect(md.IsStreamingServer()).To(BeTrue())
+			})
+
+			g.It("should report errors in .proto sources", func() {
+				_, err := Parse([]byte(`
+grpc:
+  descriptors:
+    - "missing.proto"
+`))
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(ContainSubstring("Failed to read gRPC descriptors"))
+				Expect(err.Error()).To(ContainSubstring("missing.proto"))
+			})
+		})
+
+		g.Describe("Validation", func() {
+			g.It("should require descriptors for gRPC responses", func() {
+				_, err := Parse([]byte(`
+routes:
+  - endpoint: "/duty.orders.v1.Orders/GetOrder"
+    response:
+      grpc:
+        status: "NOT_FOUND"
+`))
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(ContainSubstring("3:5: routes[0]: grpc responses require grpc descriptors"))
+			})
+
+			g.It("should report unknown statuses", func() {
+				_, err := Parse([]byte(`
+grpc:
+  descriptors:
+    - "orders.protoset"
+
+routes:
+  - endpoint: "/duty.orders.v1.Orders/GetOrder"
+    response:
+      grpc:
+        status: "LOST"
+`))
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(ContainSubstring(`routes[0].response.grpc.status: unknown gRPC status "LOST"`))
+			})
+		})
+	})
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Host represents the options specific to requests made against a given host.
+// The host may be an exact name, or a wildcard such as `*.example.test`.
+type Host struct {
+	Host     string     `yaml:"host,omitempty"`
+	NotFound Response   `yaml:"notFound,omitempty"`
+	Source   string     `yaml:"-"`
+	node     *yaml.Node `yaml:"-"`
+	position int        `yaml:"-"`
+}
+
+// matchHost reports whether the given request hostname satisfies the host
+// pattern. Wildcard patterns match any subdomain of the given domain.
+func matchHost(pattern, hostname string) bool {
+	pattern = strings.ToLower(pattern)
+
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[1:]
+		return len(hostname) > len(suffix) && strings.HasSuffix(hostname, suffix)
+	}
+
+	return pattern == hostname
+}
+
+// hostname strips any port from the host header value and normalizes it for
+// matching against host patterns.
+func hostname(host string) string {
+	h, _, err := net.SplitHostPort(host)
+	if err != nil {
+		h = host
+	}
+
+	return strings.ToLower(strings.Trim(h, "[]"))
+}
+
+// sortHosts orders host patterns from most to least specific, placing exact
+// names ahead of wildcards and longer wildcards ahead of shorter ones.
+func sortHosts(hosts []string) {
+	sort.SliceStable(hosts, func(i, j int) bool {
+		return moreSpecific(hosts[i], hosts[j])
+	})
+}
+
+// moreSpecific reports whether host pattern a should be preferred over b.
+func moreSpecific(a, b string) bool {
+	wa := strings.HasPrefix(a, "*.")
+	wb := strings.HasPrefix(b, "*.")
+
+	if wa != wb {
+		return !wa
+	}
+
+	return len(a) > len(b)
+}
+
+func routeKey(host, endpoint string) string {
+	return strings.ToLower(host) + endpoint
+}
@@ -0,0 +1,504 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats a config file may be written in
+const (
+	YAMLFormat = "yaml"
+	JSONFormat = "json"
+	TOMLFormat = "toml"
+)
+
+var (
+	configExtensions = map[string]string{
+		".yaml": YAMLFormat,
+		".yml":  YAMLFormat,
+		".json": JSONFormat,
+		".toml": TOMLFormat,
+	}
+)
+
+// loader reads config files and merges them, along with the files they
+// include, into a single File. Options other than routes and hosts may only be
+// set by one of the files.
+type loader struct {
+	conf     *File
+	loaded   map[string]bool
+	owners   map[string]string
+	problems []Problem
+}
+
+func newLoader() *loader {
+	return &loader{
+		conf:   &File{},
+		loaded: make(map[string]bool),
+		owners: make(map[string]string),
+	}
+}
+
+// load reads the config file at the given path, or every config file within it
+// when the path is a directory, and returns the merged and validated File. The
+// format given applies to the file at the path, and is otherwise detected from
+// the extension of each file read.
+func load(path, format string) (*File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config file: %v", err.Error())
+	}
+
+	l := newLoader()
+
+	if info.IsDir() {
+		err = l.loadDir(path)
+	} else {
+		err = l.loadFile(path, format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return l.finish()
+}
+
+// loadBytes decodes the given YAML, reading any files it includes relative to
+// the working directory, and returns the merged and validated File.
+func loadBytes(b []byte) (*File, error) {
+	l := newLoader()
+
+	err := l.add(b, "", ".", YAMLFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.finish()
+}
+
+func (l *loader) loadDir(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("Failed to read config directory: %v", err.Error())
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !isConfigFile(e.Name()) {
+			continue
+		}
+
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("Failed to read config directory: no config files found in %v", dir)
+	}
+
+	sort.Strings(files)
+
+	for _, file := range files {
+		err = l.loadFile(file, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *loader) loadFile(path, format string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read config file: %v", err.Error())
+	}
+
+	if l.loaded[abs] {
+		return nil
+	}
+
+	l.loaded[abs] = true
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read config file: %v", err.Error())
+	}
+
+	if format == "" {
+		format = formatOf(path)
+	}
+
+	return l.add(b, path, filepath.Dir(path), format)
+}
+
+// add decodes the named config and expands the environment variables its
+// values refer to, merges it into the File, and then loads the files it
+// includes relative to the given directory.
+func (l *loader) add(b []byte, name, dir, format string) error {
+	root, err := decodeNode(b, format)
+	if err != nil {
+		return unmarshalError(name, err)
+	}
+
+	l.problems = append(l.problems, interpolate(root, name)...)
+
+	var part File
+	doc := root
+	if len(root.Content) > 0 {
+		doc = root.Content[0]
+
+		err = root.Decode(&part)
+		if err != nil {
+			return unmarshalError(name, err)
+		}
+
+		l.problems = append(l.problems, unknownKeys(doc, reflect.TypeOf(&part), name)...)
+	}
+
+	part.source = name
+	part.node = doc
+
+	if l.conf.node == nil {
+		l.conf.source = name
+		l.conf.node = doc
+	}
+
+	l.merge(&part)
+
+	for i, pattern := range part.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			l.problem(&part, fmt.Sprintf("invalid include pattern: %v", err.Error()), "include", i)
+			continue
+		}
+
+		if len(matches) == 0 {
+			l.problem(&part, fmt.Sprintf("no config files match %v", pattern), "include", i)
+			continue
+		}
+
+		for _, m := range matches {
+			err = l.loadFile(m, "")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// merge appends the routes and hosts of the part to the File, remembering the
+// file and node each was read from, and takes any other options the part sets
+// that no earlier file has.
+func (l *loader) merge(part *File) {
+	routes := child(part.node, "routes")
+	for i := range part.Routes {
+		r := part.Routes[i]
+		r.Source = part.source
+		r.position = i
+		r.node = child(routes, i)
+
+		l.conf.Routes = append(l.conf.Routes, r)
+	}
+
+	hosts := child(part.node, "hosts")
+	for i := range part.Hosts {
+		h := part.Hosts[i]
+		h.Source = part.source
+		h.position = i
+		h.node = child(hosts, i)
+
+		l.conf.Hosts = append(l.conf.Hosts, h)
+	}
+
+	if l.claim(part, part.NotFound != nil, "notFound") {
+		l.conf.NotFound = part.NotFound
+	}
+
+	if l.claim(part, part.CORS != nil, "cors") {
+		l.conf.CORS = part.CORS
+	}
+
+	if l.claim(part, part.Log.Level != "", "log", "level") {
+		l.conf.Log.Level = part.Log.Level
+	}
+
+	if l.claim(part, part.Log.Access != nil, "log", "access") {
+		l.conf.Log.Access = part.Log.Access
+	}
+
+	if l.claim(part, part.OpenAPI != nil, "openapi") {
+		l.conf.OpenAPI = part.OpenAPI
+	}
+
+	if l.claim(part, part.GRPC != nil, "grpc") {
+		l.conf.GRPC = part.GRPC
+	}
+
+	if l.claim(part, part.Status != "", "status") {
+		l.conf.Status = part.Status
+	}
+
+	if l.claim(part, part.Reset != "", "reset") {
+		l.conf.Reset = part.Reset
+	}
+
+	if l.claim(part, part.Set != "", "set") {
+		l.conf.Set = part.Set
+	}
+
+	if l.claim(part, part.Metrics != "", "metrics") {
+		l.conf.Metrics = part.Metrics
+	}
+
+	if l.claim(part, part.Export != "", "export") {
+		l.conf.Export = part.Export
+	}
+}
+
+// claim reports whether the part may set the option at the path, recording a
+// problem when an earlier file has already set it.
+func (l *loader) claim(part *File, set bool, path ...interface{}) bool {
+	if !set {
+		return false
+	}
+
+	key := formatPath(path)
+
+	owner, claimed := l.owners[key]
+	if !claimed {
+		l.owners[key] = part.source
+		return true
+	}
+
+	msg := fmt.Sprintf("%v is already set", key)
+	if owner != "" {
+		msg = fmt.Sprintf("%v by %v", msg, owner)
+	}
+
+	l.problem(part, msg, path...)
+
+	return false
+}
+
+func (l *loader) problem(part *File, msg string, path ...interface{}) {
+	p := []Problem{{
+		Path:    formatPath(path),
+		Message: msg,
+		path:    path,
+	}}
+
+	locate(p, part.node, part.source)
+
+	l.problems = append(l.problems, p...)
+}
+
+// finish validates the merged File, returning a ValidationError locating every
+// problem found within the files read.
+func (l *loader) finish() (*File, error) {
+	problems := l.conf.Validate()
+	l.conf.locate(problems)
+
+	problems = append(l.problems, problems...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return l.conf, nil
+}
+
+// locate sets the file, line and column of each problem found validating the
+// File, relative to the file its route or host was read from.
+func (f *File) locate(problems []Problem) {
+	if f.node == nil {
+		return
+	}
+
+	for i := range problems {
+		path := problems[i].path
+
+		if len(path) > 1 {
+			idx, _ := path[1].(int)
+
+			var (
+				node     *yaml.Node
+				source   string
+				position int
+			)
+
+			switch path[0] {
+			case "routes":
+				r := f.Routes[idx]
+				node, source, position = r.node, r.Source, r.position
+
+			case "hosts":
+				h := f.Hosts[idx]
+				node, source, position = h.node, h.Source, h.position
+			}
+
+			if node != nil {
+				rel := append([]interface{}{path[0], position}, path[2:]...)
+				problems[i].Path = formatPath(rel)
+				problems[i].path = rel
+
+				locateAt(&problems[i], node, path[2:], source)
+				continue
+			}
+		}
+
+		locateAt(&problems[i], f.node, path, f.source)
+	}
+}
+
+func unmarshalError(name string, err error) error {
+	if name == "" {
+		return fmt.Errorf("Failed to unmarshal config file: %v", err.Error())
+	}
+
+	return fmt.Errorf("Failed to unmarshal config file %v: %v", name, err.Error())
+}
+
+// decodeNode decodes the config in the given format. JSON is decoded as the
+// YAML it is a subset of, while TOML is converted into nodes without positions,
+// so problems found in TOML files are reported by path alone.
+func decodeNode(b []byte, format string) (*yaml.Node, error) {
+	var root yaml.Node
+
+	switch format {
+	case YAMLFormat, JSONFormat:
+		err := yaml.Unmarshal(b, &root)
+		if err != nil {
+			return nil, err
+		}
+
+	case TOMLFormat:
+		var m map[string]interface{}
+		_, err := toml.Decode(string(b), &m)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(m) > 0 {
+			var doc yaml.Node
+			err = doc.Encode(m)
+			if err != nil {
+				return nil, err
+			}
+
+			root.Kind = yaml.DocumentNode
+			root.Content = []*yaml.Node{&doc}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+
+	return &root, nil
+}
+
+// unknownKeys returns a problem locating each key within the node that is not
+// an option of the type it is decoded into, so misspelled options are reported
+// rather than silently ignored.
+func unknownKeys(n *yaml.Node, t reflect.Type, file string, path ...interface{}) []Problem {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var problems []Problem
+
+	switch {
+	case n.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for i, item := range n.Content {
+			at := append(append([]interface{}{}, path...), i)
+			problems = append(problems, unknownKeys(item, t.Elem(), file, at...)...)
+		}
+
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			at := append(append([]interface{}{}, path...), n.Content[i].Value)
+			problems = append(problems, unknownKeys(n.Content[i+1], t.Elem(), file, at...)...)
+		}
+
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := yamlFields(t)
+
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			at := append(append([]interface{}{}, path...), key.Value)
+
+			field, known := fields[key.Value]
+			if !known {
+				problems = append(problems, Problem{
+					File:    file,
+					Line:    key.Line,
+					Column:  key.Column,
+					Path:    formatPath(at),
+					Message: fmt.Sprintf("unknown option %v", key.Value),
+					path:    at,
+				})
+
+				continue
+			}
+
+			problems = append(problems, unknownKeys(n.Content[i+1], field, file, at...)...)
+		}
+	}
+
+	return problems
+}
+
+// yamlFields returns the type of each exported field of the struct by the key
+// it is decoded from
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		switch key {
+		case "-":
+			continue
+
+		case "":
+			key = strings.ToLower(f.Name)
+		}
+
+		fields[key] = f.Type
+	}
+
+	return fields
+}
+
+// formatOf returns the format of the config file from its extension, treating
+// files without a known extension as YAML
+func formatOf(name string) string {
+	f, found := configExtensions[strings.ToLower(filepath.Ext(name))]
+	if !found {
+		return YAMLFormat
+	}
+
+	return f
+}
+
+func isConfigFile(name string) bool {
+	_, found := configExtensions[strings.ToLower(filepath.Ext(name))]
+	return found
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/ledger"
+	"github.com/gomicro/penname"
+	. "github.com/onsi/gomega"
+)
+
+func TestLoad(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Loading", func() {
+		g.Before(func() {
+			mw := penname.New()
+			log = ledger.New(mw, ledger.DebugLevel)
+		})
+
+		g.It("should merge the routes of included files", func() {
+			c, err := ParseFile("./duty_include.yaml")
+			Expect(err).To(BeNil())
+
+			Expect(len(c.Routes)).To(Equal(4))
+			Expect(c.Routes[0].Endpoint).To(Equal("/v1/ping"))
+			Expect(c.Routes[0].Source).To(Equal("./duty_include.yaml"))
+			Expect(c.Routes[1].Endpoint).To(Equal("/v1/accounts"))
+			Expect(c.Routes[1].Source).To(Equal("duty.d/accounts.yaml"))
+			Expect(c.Routes[3].Endpoint).To(Equal("/v1/orders"))
+			Expect(c.Routes[3].Source).To(Equal("duty.d/orders.yaml"))
+			Expect(c.Status).To(Equal("/accounts/status"))
+
+			server := httptest.NewServer(c)
+			defer server.Close()
+
+			res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/v1/accounts"))
+			Expect(err).To(BeNil())
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal(`{"accounts": []}`))
+		})
+
+		g.It("should load every config file in a directory", func() {
+			os.Setenv("DUTY_CONFIG_FILE", "./duty.d")
+			defer os.Unsetenv("DUTY_CONFIG_FILE")
+
+			c, err := ParseFromFile()
+			Expect(err).To(BeNil())
+
+			Expect(len(c.Routes)).To(Equal(3))
+			Expect(c.Routes[0].Source).To(Equal("duty.d/accounts.yaml"))
+			Expect(c.Routes[2].Source).To(Equal("duty.d/orders.yaml"))
+		})
+
+		g.It("should return an error for a directory without config files", func() {
+			dir, err := ioutil.TempDir("", "duty")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			_, err = ParseFile(dir)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("no config files found"))
+		})
+
+		g.It("should locate conflicts between files", func() {
+			err := ValidateFile("./duty_conflict.yaml")
+			Expect(err).NotTo(BeNil())
+
+			var verr *ValidationError
+			Expect(errors.As(err, &verr)).To(BeTrue())
+
+			var lines []string
+			for _, p := range verr.Problems {
+				lines = append(lines, p.String())
+			}
+
+			Expect(lines).To(Equal([]string{
+				`duty.d/accounts.yaml:2:9: status: status is already set by ./duty_conflict.yaml`,
+				`./duty_conflict.yaml:6:5: include[1]: no config files match missing/*.yaml`,
+				`duty.d/accounts.yaml:5:15: routes[0].endpoint: duplicate endpoint /v1/accounts, also defined by routes[1] in ./duty_conflict.yaml`,
+			}))
+		})
+	})
+}
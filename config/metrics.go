@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+)
+
+// metrics collects the counters and histograms exposed on the metrics endpoint
+// in the Prometheus text format
+type metrics struct {
+	mu        sync.Mutex
+	requests  map[requestLabels]uint64
+	unmatched map[string]uint64
+	faults    map[faultLabels]uint64
+	latency   map[string]*histogram
+}
+
+type requestLabels struct {
+	route  string
+	method string
+	code   int
+}
+
+type faultLabels struct {
+	route string
+	fault string
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:  make(map[requestLabels]uint64),
+		unmatched: make(map[string]uint64),
+		faults:    make(map[faultLabels]uint64),
+		latency:   make(map[string]*histogram),
+	}
+}
+
+// observe records a served request. Requests for the control endpoints are
+// neither matched nor unmatched and are not recorded.
+func (m *metrics) observe(rec *accessRecorder, req *http.Request, entry *accessEntry, d time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry.unmatched {
+		m.unmatched[req.Method]++
+		return
+	}
+
+	if entry.route == nil {
+		return
+	}
+
+	route := routeLabel(entry.route)
+
+	m.requests[requestLabels{route: route, method: req.Method, code: rec.status}]++
+
+	if entry.fault != "" {
+		m.faults[faultLabels{route: route, fault: entry.fault}]++
+	}
+
+	h, ok := m.latency[route]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		m.latency[route] = h
+	}
+
+	h.observe(d.Seconds())
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range latencyBuckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += v
+}
+
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP duty_requests_total Requests served by a configured route.")
+	fmt.Fprintln(w, "# TYPE duty_requests_total counter")
+
+	reqs := make([]requestLabels, 0, len(m.requests))
+	for l := range m.requests {
+		reqs = append(reqs, l)
+	}
+
+	sort.Slice(reqs, func(i, j int) bool {
+		if reqs[i].route != reqs[j].route {
+			return reqs[i].route < reqs[j].route
+		}
+
+		if reqs[i].method != reqs[j].method {
+			return reqs[i].method < reqs[j].method
+		}
+
+		return reqs[i].code < reqs[j].code
+	})
+
+	for _, l := range reqs {
+		fmt.Fprintf(w, "duty_requests_total{route=\"%v\",method=\"%v\",code=\"%v\"} %v\n", escapeLabel(l.route), escapeLabel(l.method), l.code, m.requests[l])
+	}
+
+	fmt.Fprintln(w, "# HELP duty_unmatched_requests_total Requests that did not match any route.")
+	fmt.Fprintln(w, "# TYPE duty_unmatched_requests_total counter")
+
+	for _, method := range sortedKeys(m.unmatched) {
+		fmt.Fprintf(w, "duty_unmatched_requests_total{method=\"%v\"} %v\n", escapeLabel(method), m.unmatched[method])
+	}
+
+	fmt.Fprintln(w, "# HELP duty_faults_injected_total Faults deliberately injected into responses.")
+	fmt.Fprintln(w, "# TYPE duty_faults_injected_total counter")
+
+	faults := make([]faultLabels, 0, len(m.faults))
+	for l := range m.faults {
+		faults = append(faults, l)
+	}
+
+	sort.Slice(faults, func(i, j int) bool {
+		if faults[i].route != faults[j].route {
+			return faults[i].route < faults[j].route
+		}
+
+		return faults[i].fault < faults[j].fault
+	})
+
+	for _, l := range faults {
+		fmt.Fprintf(w, "duty_faults_injected_total{route=\"%v\",fault=\"%v\"} %v\n", escapeLabel(l.route), escapeLabel(l.fault), m.faults[l])
+	}
+
+	fmt.Fprintln(w, "# HELP duty_response_duration_seconds Time taken to respond to requests by route.")
+	fmt.Fprintln(w, "# TYPE duty_response_duration_seconds histogram")
+
+	routes := make([]string, 0, len(m.latency))
+	for r := range m.latency {
+		routes = append(routes, r)
+	}
+	sort.Strings(routes)
+
+	for _, r := range routes {
+		h := m.latency[r]
+		route := escapeLabel(r)
+
+		for i, b := range latencyBuckets {
+			fmt.Fprintf(w, "duty_response_duration_seconds_bucket{route=\"%v\",le=\"%v\"} %v\n", route, b, h.counts[i])
+		}
+
+		fmt.Fprintf(w, "duty_response_duration_seconds_bucket{route=\"%v\",le=\"+Inf\"} %v\n", route, h.count)
+		fmt.Fprintf(w, "duty_response_duration_seconds_sum{route=\"%v\"} %v\n", route, h.sum)
+		fmt.Fprintf(w, "duty_response_duration_seconds_count{route=\"%v\"} %v\n", route, h.count)
+	}
+}
+
+func handleMetrics(w http.ResponseWriter, req *http.Request, f *File) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+
+	if f.metrics != nil {
+		f.metrics.write(w)
+	}
+}
+
+// routeLabel identifies a route in metrics by its name, or by its host and
+// endpoint when it has no name.
+func routeLabel(r *Route) string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	return r.Host + r.Endpoint
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestMetrics(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Metrics", func() {
+		var server *httptest.Server
+
+		g.BeforeEach(func() {
+			f, _ := ParseFromFile()
+			server = httptest.NewServer(f)
+		})
+
+		g.AfterEach(func() {
+			server.Close()
+		})
+
+		g.It("should count requests by route, method and code", func() {
+			for _, p := range []string{"/v1/ordinal", "/v1/ordinal", "/v1/ordinal", "/v1/missing", "/duty/status"} {
+				res, err := http.Get(fmt.Sprintf("%v%v", server.URL, p))
+				Expect(err).To(BeNil())
+				res.Body.Close()
+			}
+
+			res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/duty/metrics"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+
+			Expect(res.Header.Get("Content-Type")).To(ContainSubstring("text/plain"))
+			Expect(string(b)).To(ContainSubstring(`duty_requests_total{route="/v1/ordinal",method="GET",code="200"} 1`))
+			Expect(string(b)).To(ContainSubstring(`duty_requests_total{route="/v1/ordinal",method="GET",code="401"} 2`))
+			Expect(string(b)).To(ContainSubstring(`duty_unmatched_requests_total{method="GET"} 1`))
+			Expect(string(b)).To(ContainSubstring(`duty_response_duration_seconds_count{route="/v1/ordinal"} 3`))
+			Expect(string(b)).To(ContainSubstring(`duty_response_duration_seconds_bucket{route="/v1/ordinal",le="+Inf"} 3`))
+			Expect(string(b)).NotTo(ContainSubstring(`/duty/status`))
+		})
+	})
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gomicro/duty/openapi"
+)
+
+type violationsKey struct{}
+
+// OpenAPI represents an OpenAPI document that requests are validated against.
+// Routes naming an operation are validated against that operation, and when
+// validate is set every other route is validated against the operation
+// documented for the method and path of the request. Requests that do not
+// satisfy their operation are answered with the violation response, which
+// defaults to a 400 with a JSON list of the violations found.
+type OpenAPI struct {
+	Spec      string    `yaml:"spec,omitempty"`
+	Validate  bool      `yaml:"validate,omitempty"`
+	Violation *Response `yaml:"violation,omitempty"`
+	doc       *openapi.Document
+}
+
+type violationBody struct {
+	Error      string              `json:"error"`
+	Operation  string              `json:"operation,omitempty"`
+	Violations []openapi.Violation `json:"violations"`
+}
+
+// load reads the OpenAPI document and checks every operation named by the
+// routes is documented within it
+func (o *OpenAPI) load(routes []Route) error {
+	b, err := ioutil.ReadFile(o.Spec)
+	if err != nil {
+		return fmt.Errorf("Failed to read OpenAPI spec: %v", err.Error())
+	}
+
+	doc, err := openapi.Parse(b)
+	if err != nil {
+		return fmt.Errorf("Failed to read OpenAPI spec: %v", err.Error())
+	}
+
+	for _, r := range routes {
+		if r.Operation == "" {
+			continue
+		}
+
+		_, found := doc.OperationByID(r.Operation)
+		if !found {
+			return fmt.Errorf("route %v names operation %v, which is not documented in %v", r.Endpoint, r.Operation, o.Spec)
+		}
+	}
+
+	if o.Violation != nil && o.Violation.Code == 0 {
+		o.Violation.Code = http.StatusBadRequest
+	}
+
+	o.doc = doc
+
+	return nil
+}
+
+// check validates the request to the route against its operation, returning
+// the name of the operation and the violations found. The body of the request
+// is restored for the route to serve.
+func (o *OpenAPI) check(route *Route, req *http.Request) (string, []openapi.Violation) {
+	if o.doc == nil {
+		return "", nil
+	}
+
+	var op *openapi.Operation
+
+	switch {
+	case route.Operation != "":
+		op, _ = o.doc.OperationByID(route.Operation)
+
+	case o.Validate:
+		op, _, _ = o.doc.FindOperation(req.Method, req.URL.Path)
+		if op == nil {
+			return "", []openapi.Violation{{
+				In:      "path",
+				Message: fmt.Sprintf("no operation documented for %v %v", req.Method, req.URL.Path),
+			}}
+		}
+	}
+
+	if op == nil {
+		return "", nil
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	return operationName(op), o.doc.ValidateRequest(op, req, body)
+}
+
+// reject responds to a request that does not satisfy its operation, logging
+// the violations found
+func (o *OpenAPI) reject(w http.ResponseWriter, req *http.Request, operation string, vs []openapi.Violation) {
+	msgs := make([]string, len(vs))
+	for i, v := range vs {
+		msgs[i] = v.String()
+	}
+
+	log.Warnf("request %v %v violates %v: %v", req.Method, req.URL.Path, orUndocumented(operation), strings.Join(msgs, "; "))
+	recordViolations(req, msgs)
+
+	req = req.WithContext(context.WithValue(req.Context(), violationsKey{}, vs))
+
+	resp := o.Violation
+	if resp != nil && (resp.Body != "" || resp.Payload != "" || resp.Template != "") {
+		resp.write(w, req)
+		return
+	}
+
+	code := http.StatusBadRequest
+	if resp != nil {
+		code = resp.Code
+
+		for k, v := range resp.Headers {
+			w.Header().Set(k, v)
+		}
+	}
+
+	b, _ := json.Marshal(violationBody{
+		Error:      fmt.Sprintf("request does not satisfy %v", orUndocumented(operation)),
+		Operation:  operation,
+		Violations: vs,
+	})
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	w.WriteHeader(code)
+	w.Write(b) //nolint:errcheck
+}
+
+// operationName returns the ID of the operation, or its method and path when
+// it has none
+func operationName(op *openapi.Operation) string {
+	if op.OperationID != "" {
+		return op.OperationID
+	}
+
+	return op.Method + " " + op.Path
+}
+
+func orUndocumented(operation string) string {
+	if operation == "" {
+		return "any documented operation"
+	}
+
+	return operation
+}
+
+func requestViolations(req *http.Request) []openapi.Violation {
+	vs, _ := req.Context().Value(violationsKey{}).([]openapi.Violation)
+	return vs
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/ledger"
+	"github.com/gomicro/penname"
+	. "github.com/onsi/gomega"
+)
+
+func TestOpenAPI(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("OpenAPI Validation", func() {
+		var server *httptest.Server
+
+		g.Before(func() {
+			mw := penname.New()
+			log = ledger.New(mw, ledger.DebugLevel)
+
+			f, err := ParseFile("./duty_openapi.yaml")
+			Expect(err).To(BeNil())
+
+			server = httptest.NewServer(f)
+		})
+
+		g.After(func() {
+			server.Close()
+		})
+
+		do := func(method, path, body string, header http.Header) (*http.Response, violationBody) {
+			req, err := http.NewRequest(method, fmt.Sprintf("%v%v", server.URL, path), strings.NewReader(body))
+			Expect(err).To(BeNil())
+
+			for k, v := range header {
+				req.Header[k] = v
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			var vb violationBody
+			if res.StatusCode == http.StatusBadRequest {
+				Expect(json.NewDecoder(res.Body).Decode(&vb)).To(BeNil())
+			}
+
+			return res, vb
+		}
+
+		requestID := http.Header{"X-Request-Id": []string{"abc"}}
+
+		g.It("should serve requests satisfying their operation", func() {
+			res, _ := do("GET", "/v1/pets?limit=10", "", requestID)
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+			res, _ = do("POST", "/v1/pets", `{"name": "Rex", "tag": "dog"}`, http.Header{"Content-Type": []string{"application/json"}})
+			Expect(res.StatusCode).To(Equal(http.StatusCreated))
+
+			res, _ = do("GET", "/v1/pets/7", "", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		g.It("should list query and header violations", func() {
+			res, vb := do("GET", "/v1/pets?limit=500", "", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(vb.Operation).To(Equal("listPets"))
+			Expect(vb.Violations).To(HaveLen(2))
+			Expect(vb.Violations[0].String()).To(Equal("query limit must be at most 100"))
+			Expect(vb.Violations[1].String()).To(Equal("header X-Request-ID is required"))
+		})
+
+		g.It("should list path parameter violations", func() {
+			res, vb := do("GET", "/v1/pets/rex", "", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(vb.Violations[0].String()).To(Equal("path petId must be of type integer"))
+		})
+
+		g.It("should list body violations", func() {
+			res, vb := do("POST", "/v1/pets", `{"tag": "fish", "age": 3}`, http.Header{"Content-Type": []string{"application/json"}})
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+
+			var msgs []string
+			for _, v := range vb.Violations {
+				msgs = append(msgs, v.String())
+			}
+
+			Expect(msgs).To(Equal([]string{
+				"body name is required",
+				"body age is not an allowed property",
+				"body tag must be one of dog, cat",
+			}))
+
+			res, vb = do("POST", "/v1/pets", "", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(vb.Violations[0].String()).To(Equal("body is required"))
+		})
+
+		g.It("should reject requests with no documented operation", func() {
+			res, vb := do("GET", "/v1/undocumented", "", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(vb.Violations[0].Message).To(Equal("no operation documented for GET /v1/undocumented"))
+		})
+
+		g.It("should validate routes against the operation they name", func() {
+			res, vb := do("GET", "/v2/pets/7", "", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(vb.Operation).To(Equal("showPet"))
+			Expect(vb.Violations[0].Message).To(Equal("does not match /v1/pets/{petId}"))
+		})
+
+		g.It("should refuse routes naming an undocumented operation", func() {
+			_, err := Parse([]byte(`
+openapi:
+  spec: "petstore.yaml"
+routes:
+  - endpoint: "/v1/foo"
+    operation: "missing"
+    response:
+      code: 200
+`))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("names operation missing"))
+		})
+	})
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type paramsKey struct{}
+
+// parameterized reports whether the endpoint has path parameters, written as
+// segments such as `/v1/users/{id}`
+func parameterized(endpoint string) bool {
+	return strings.Contains(endpoint, "{")
+}
+
+// matchEndpoint matches the request path against a parameterized endpoint,
+// returning the value of each parameter. Each parameter matches exactly one
+// non-empty segment of the path.
+func matchEndpoint(endpoint, path string) (map[string]string, bool) {
+	want := strings.Split(endpoint, "/")
+	got := strings.Split(path, "/")
+
+	if len(want) != len(got) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, seg := range want {
+		name, ok := paramName(seg)
+		if !ok {
+			if seg != got[i] {
+				return nil, false
+			}
+
+			continue
+		}
+
+		if got[i] == "" {
+			return nil, false
+		}
+
+		params[name] = got[i]
+	}
+
+	return params, true
+}
+
+// paramName returns the name of the parameter the segment of an endpoint
+// captures, if it is one
+func paramName(seg string) (string, bool) {
+	if len(seg) < 2 || seg[0] != '{' || seg[len(seg)-1] != '}' {
+		return "", false
+	}
+
+	return seg[1 : len(seg)-1], true
+}
+
+// literalSegments counts the segments of an endpoint that are not parameters,
+// so endpoints with more literal segments are preferred when several match.
+func literalSegments(endpoint string) int {
+	n := 0
+	for _, seg := range strings.Split(endpoint, "/") {
+		if _, ok := paramName(seg); !ok {
+			n++
+		}
+	}
+
+	return n
+}
+
+// endpointShape returns the endpoint with the names of its parameters removed,
+// as endpoints differing only by parameter names match the same paths.
+func endpointShape(endpoint string) string {
+	segs := strings.Split(endpoint, "/")
+	for i, seg := range segs {
+		if _, ok := paramName(seg); ok {
+			segs[i] = "{}"
+		}
+	}
+
+	return strings.Join(segs, "/")
+}
+
+func withParams(r *http.Request, params map[string]string) *http.Request {
+	if len(params) == 0 {
+		return r
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), paramsKey{}, params))
+}
+
+func requestParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	return params
+}
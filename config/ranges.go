@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Range modes determining how payload responses answer range requests
+const (
+	AutoRanges      = "auto"
+	OffRanges       = "off"
+	IgnoreRanges    = "ignore"
+	MisreportRanges = "misreport"
+)
+
+var (
+	rangeModes = []string{"", AutoRanges, OffRanges, IgnoreRanges, MisreportRanges}
+
+	errUnsatisfiable = errors.New("range not satisfiable")
+)
+
+// byteRange represents a range of bytes of a body, from start up to and
+// including end
+type byteRange struct {
+	start int64
+	end   int64
+}
+
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %v-%v/%v", r.start, r.end, size)
+}
+
+// rangeable reports whether the response serves range requests, which only
+// successful responses with a payload file do unless ranges are off
+func (resp *Response) rangeable() bool {
+	return resp.Code == http.StatusOK &&
+		resp.Payload != "" && resp.Body == "" && resp.Template == "" &&
+		!strings.EqualFold(resp.Ranges, OffRanges)
+}
+
+// writeRange answers a range request for the body with the partial content it
+// asks for, reporting whether it did so. Ranges that can not be satisfied are
+// answered with a 416, and several ranges with a multipart body. Responses set
+// to ignore ranges advertise support but return the whole body, and those set
+// to misreport ranges claim each part starts a byte later than it does.
+func (resp *Response) writeRange(w http.ResponseWriter, req *http.Request, b []byte) bool {
+	if !resp.rangeable() {
+		return false
+	}
+
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	header := req.Header.Get("Range")
+	if header == "" || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return false
+	}
+
+	if !ifRange(w, req) {
+		return false
+	}
+
+	mode := strings.ToLower(resp.Ranges)
+	if mode == IgnoreRanges {
+		recordFault(req, "ignored_range")
+		return false
+	}
+
+	size := int64(len(b))
+
+	ranges, err := parseRanges(header, size)
+	if err != nil {
+		w.Header().Del("Content-Type")
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%v", size))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		w.Write([]byte(err.Error())) //nolint:errcheck
+		return true
+	}
+
+	reported := ranges
+	if mode == MisreportRanges {
+		recordFault(req, "wrong_content_range")
+
+		reported = make([]byteRange, len(ranges))
+		for i, r := range ranges {
+			reported[i] = byteRange{start: r.start + 1, end: r.end + 1}
+		}
+	}
+
+	if len(ranges) == 1 {
+		r := ranges[0]
+
+		w.Header().Set("Content-Range", reported[0].contentRange(size))
+		w.Header().Set("Content-Length", strconv.FormatInt(r.end-r.start+1, 10))
+		w.WriteHeader(http.StatusPartialContent)
+
+		if req.Method != http.MethodHead {
+			w.Write(b[r.start : r.end+1]) //nolint:errcheck
+		}
+
+		return true
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	ct := w.Header().Get("Content-Type")
+
+	for i, r := range ranges {
+		h := textproto.MIMEHeader{}
+		if ct != "" {
+			h.Set("Content-Type", ct)
+		}
+		h.Set("Content-Range", reported[i].contentRange(size))
+
+		pw, err := mw.CreatePart(h)
+		if err != nil {
+			break
+		}
+
+		pw.Write(b[r.start : r.end+1]) //nolint:errcheck
+	}
+
+	mw.Close()
+
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusPartialContent)
+
+	if req.Method != http.MethodHead {
+		w.Write(buf.Bytes()) //nolint:errcheck
+	}
+
+	return true
+}
+
+// ifRange reports whether the If-Range condition of the request, if any, holds
+// against the validators already set on the response
+func ifRange(w http.ResponseWriter, req *http.Request) bool {
+	ir := req.Header.Get("If-Range")
+	if ir == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ir, `"`) || strings.HasPrefix(ir, `W/"`) {
+		return matchETag(ir, w.Header().Get("ETag"), false)
+	}
+
+	lm := w.Header().Get("Last-Modified")
+
+	return lm != "" && ir == lm
+}
+
+// parseRanges reads the byte ranges of a Range header for a body of the given
+// size, clamping ranges that run past its end
+func parseRanges(header string, size int64) ([]byteRange, error) {
+	const prefix = "bytes="
+
+	if !strings.HasPrefix(header, prefix) {
+		return nil, fmt.Errorf("invalid range %q", header)
+	}
+
+	var ranges []byteRange
+
+	for _, spec := range strings.Split(header[len(prefix):], ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		i := strings.Index(spec, "-")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid range %q", spec)
+		}
+
+		first, last := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+
+		var r byteRange
+
+		if first == "" {
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid range %q", spec)
+			}
+
+			if n == 0 || size == 0 {
+				continue
+			}
+
+			if n > size {
+				n = size
+			}
+
+			r = byteRange{start: size - n, end: size - 1}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, fmt.Errorf("invalid range %q", spec)
+			}
+
+			if start >= size {
+				continue
+			}
+
+			end := size - 1
+			if last != "" {
+				end, err = strconv.ParseInt(last, 10, 64)
+				if err != nil || end < start {
+					return nil, fmt.Errorf("invalid range %q", spec)
+				}
+
+				if end >= size {
+					end = size - 1
+				}
+			}
+
+			r = byteRange{start: start, end: end}
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	if len(ranges) == 0 {
+		return nil, errUnsatisfiable
+	}
+
+	return ranges, nil
+}
+
+func containsRangeMode(mode string) bool {
+	for _, m := range rangeModes {
+		if m == mode {
+			return true
+		}
+	}
+
+	return false
+}
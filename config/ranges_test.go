@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/ledger"
+	"github.com/gomicro/penname"
+	. "github.com/onsi/gomega"
+)
+
+const rangesConfig = `
+routes:
+  - endpoint: "/v1/download"
+    response:
+      code: 200
+      payload: "foo.json"
+      etag: "v1"
+      headers:
+        Content-Type: "application/json"
+
+  - endpoint: "/v1/off"
+    response:
+      code: 200
+      payload: "foo.json"
+      ranges: "off"
+
+  - endpoint: "/v1/ignore"
+    response:
+      code: 200
+      payload: "foo.json"
+      ranges: "ignore"
+
+  - endpoint: "/v1/misreport"
+    response:
+      code: 200
+      payload: "foo.json"
+      ranges: "misreport"
+
+  - endpoint: "/v1/inline"
+    response:
+      code: 200
+      body: "inline body"
+`
+
+func TestRanges(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Range Requests", func() {
+		var server *httptest.Server
+		var payload string
+
+		g.Before(func() {
+			mw := penname.New()
+			log = ledger.New(mw, ledger.DebugLevel)
+
+			f, err := Parse([]byte(rangesConfig))
+			Expect(err).To(BeNil())
+
+			b, err := ioutil.ReadFile("foo.json")
+			Expect(err).To(BeNil())
+			payload = string(b)
+
+			server = httptest.NewServer(f)
+		})
+
+		g.After(func() {
+			server.Close()
+		})
+
+		do := func(path string, header map[string]string) (*http.Response, string) {
+			req, err := http.NewRequest("GET", fmt.Sprintf("%v%v", server.URL, path), nil)
+			Expect(err).To(BeNil())
+
+			for k, v := range header {
+				req.Header.Set(k, v)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+
+			return res, string(b)
+		}
+
+		g.It("should advertise range support on payload responses", func() {
+			res, body := do("/v1/download", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Accept-Ranges")).To(Equal("bytes"))
+			Expect(body).To(Equal(payload))
+
+			res, _ = do("/v1/inline", nil)
+			Expect(res.Header.Get("Accept-Ranges")).To(Equal(""))
+
+			res, body = do("/v1/off", map[string]string{"Range": "bytes=0-9"})
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Accept-Ranges")).To(Equal(""))
+			Expect(body).To(Equal(payload))
+		})
+
+		g.It("should serve single ranges as partial content", func() {
+			res, body := do("/v1/download", map[string]string{"Range": "bytes=2-6"})
+			Expect(res.StatusCode).To(Equal(http.StatusPartialContent))
+			Expect(res.Header.Get("Content-Range")).To(Equal("bytes 2-6/99"))
+			Expect(body).To(Equal(payload[2:7]))
+
+			res, body = do("/v1/download", map[string]string{"Range": "bytes=-3"})
+			Expect(res.Header.Get("Content-Range")).To(Equal("bytes 96-98/99"))
+			Expect(body).To(Equal(payload[96:]))
+
+			res, body = do("/v1/download", map[string]string{"Range": "bytes=90-200"})
+			Expect(res.Header.Get("Content-Range")).To(Equal("bytes 90-98/99"))
+			Expect(body).To(Equal(payload[90:]))
+		})
+
+		g.It("should serve several ranges as multipart byteranges", func() {
+			res, body := do("/v1/download", map[string]string{"Range": "bytes=0-1, 10-12"})
+			Expect(res.StatusCode).To(Equal(http.StatusPartialContent))
+
+			mt, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+			Expect(err).To(BeNil())
+			Expect(mt).To(Equal("multipart/byteranges"))
+
+			mr := multipart.NewReader(strings.NewReader(body), params["boundary"])
+
+			part, err := mr.NextPart()
+			Expect(err).To(BeNil())
+			Expect(part.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(part.Header.Get("Content-Range")).To(Equal("bytes 0-1/99"))
+			b, _ := ioutil.ReadAll(part)
+			Expect(string(b)).To(Equal(payload[0:2]))
+
+			part, err = mr.NextPart()
+			Expect(err).To(BeNil())
+			Expect(part.Header.Get("Content-Range")).To(Equal("bytes 10-12/99"))
+			b, _ = ioutil.ReadAll(part)
+			Expect(string(b)).To(Equal(payload[10:13]))
+		})
+
+		g.It("should answer unsatisfiable ranges with 416", func() {
+			res, _ := do("/v1/download", map[string]string{"Range": "bytes=500-600"})
+			Expect(res.StatusCode).To(Equal(http.StatusRequestedRangeNotSatisfiable))
+			Expect(res.Header.Get("Content-Range")).To(Equal("bytes */99"))
+
+			res, _ = do("/v1/download", map[string]string{"Range": "bytes=9-2"})
+			Expect(res.StatusCode).To(Equal(http.StatusRequestedRangeNotSatisfiable))
+		})
+
+		g.It("should serve the whole body when If-Range does not match", func() {
+			res, _ := do("/v1/download", map[string]string{"Range": "bytes=0-1", "If-Range": `"v1"`})
+			Expect(res.StatusCode).To(Equal(http.StatusPartialContent))
+
+			res, body := do("/v1/download", map[string]string{"Range": "bytes=0-1", "If-Range": `"v0"`})
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(body).To(Equal(payload))
+		})
+
+		g.It("should ignore or misreport ranges when set to", func() {
+			res, body := do("/v1/ignore", map[string]string{"Range": "bytes=0-1"})
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Accept-Ranges")).To(Equal("bytes"))
+			Expect(body).To(Equal(payload))
+
+			res, body = do("/v1/misreport", map[string]string{"Range": "bytes=2-6"})
+			Expect(res.StatusCode).To(Equal(http.StatusPartialContent))
+			Expect(res.Header.Get("Content-Range")).To(Equal("bytes 3-7/99"))
+			Expect(body).To(Equal(payload[2:7]))
+		})
+
+		g.It("should refuse unknown range modes", func() {
+			_, err := Parse([]byte(`
+routes:
+  - endpoint: "/v1/foo"
+    response:
+      code: 200
+      ranges: "sometimes"
+`))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring(`routes[0].response.ranges: unknown ranges mode "sometimes"`))
+		})
+	})
+}
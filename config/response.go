@@ -1,9 +1,121 @@
 package config
 
-// Response represents an http response of a status code and a given payload
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"text/template"
+
+	"github.com/gomicro/duty/openapi"
+)
+
+// Response represents an http response of a status code and a given payload,
+// inline body or template, or a gRPC reply to a call of its route.
 type Response struct {
-	Code    int    `yaml:"code"`
-	Verb    string `yaml:"verb"`
-	Payload string `yaml:"payload"`
-	ID      string `yaml:"id"`
+	Code     int               `yaml:"code,omitempty"`
+	Verb     string            `yaml:"verb,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+	Body     string            `yaml:"body,omitempty"`
+	Payload  string            `yaml:"payload,omitempty"`
+	Template string            `yaml:"template,omitempty"`
+	ID       string            `yaml:"id,omitempty"`
+	ETag     string            `yaml:"etag,omitempty"`
+	Modified string            `yaml:"lastModified,omitempty"`
+	Ranges   string            `yaml:"ranges,omitempty"`
+	Stream   *Stream           `yaml:"stream,omitempty"`
+	GRPC     *GRPCResponse     `yaml:"grpc,omitempty"`
+}
+
+// templateData is the request information made available to response
+// templates
+type templateData struct {
+	Method     string
+	Host       string
+	Path       string
+	Query      url.Values
+	Header     http.Header
+	Params     map[string]string
+	Violations []openapi.Violation
+}
+
+func (resp *Response) write(w http.ResponseWriter, req *http.Request) {
+	recordResponse(req, resp)
+
+	if resp.GRPC != nil {
+		resp.GRPC.write(w, req, resp.Headers)
+		return
+	}
+
+	b, err := resp.body(req)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error())) //nolint:errcheck
+		return
+	}
+
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+
+	code, decided := resp.precondition(w, req, b)
+	if decided {
+		writePrecondition(w, code)
+		return
+	}
+
+	if resp.writeRange(w, req, b) {
+		return
+	}
+
+	if resp.Stream != nil && req.Method != http.MethodHead {
+		resp.Stream.write(w, req, resp.Code, b)
+		return
+	}
+
+	w.WriteHeader(resp.Code)
+	w.Write(b) //nolint:errcheck
+}
+
+func (resp *Response) body(req *http.Request) ([]byte, error) {
+	if resp.Template != "" {
+		t, err := template.New("response").Parse(resp.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template: %v", err.Error())
+		}
+
+		data := templateData{
+			Method:     req.Method,
+			Host:       req.Host,
+			Path:       req.URL.Path,
+			Query:      req.URL.Query(),
+			Header:     req.Header,
+			Params:     requestParams(req),
+			Violations: requestViolations(req),
+		}
+
+		var buf bytes.Buffer
+		err = t.Execute(&buf, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render template: %v", err.Error())
+		}
+
+		return buf.Bytes(), nil
+	}
+
+	if resp.Body != "" {
+		return []byte(resp.Body), nil
+	}
+
+	if resp.Payload != "" {
+		b, err := ioutil.ReadFile(resp.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payload: %v", err.Error())
+		}
+
+		return b, nil
+	}
+
+	return nil, nil
 }
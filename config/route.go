@@ -1,47 +1,88 @@
 package config
 
 import (
-	"fmt"
-	"io/ioutil"
 	"net/http"
 	"strings"
+
+	"github.com/gomicro/duty/pkg/control"
+	"gopkg.in/yaml.v3"
 )
 
+// Route types determining how a route selects from its responses
 const (
-	ordinalRouteType  = "ordinal"
-	variableRouteType = "variable"
-	verbRouteType     = "verb"
+	StaticRouteType    = "static"
+	OrdinalRouteType   = "ordinal"
+	VariableRouteType  = "variable"
+	VerbRouteType      = "verb"
+	SSERouteType       = "sse"
+	WebSocketRouteType = "websocket"
+)
+
+var (
+	// ErrInvalidRouteType is returned when setting the response of a route
+	// that is not a variable route
+	ErrInvalidRouteType = control.ErrInvalidRouteType
+
+	// ErrIDNotFound is returned when setting a route to a response id it does
+	// not have
+	ErrIDNotFound = control.ErrIDNotFound
 )
 
 // Route represents a given endpoint and the kind of response it should return
 type Route struct {
-	Endpoint  string     `yaml:"endpoint"`
-	Type      string     `yaml:"type"`
-	Response  Response   `yaml:"response"`
-	index     int        `yaml:"-"`
-	Responses []Response `yaml:"responses"`
-	Name      string     `yaml:"name"`
+	Endpoint  string            `yaml:"endpoint,omitempty"`
+	Host      string            `yaml:"host,omitempty"`
+	Type      string            `yaml:"type,omitempty"`
+	Response  Response          `yaml:"response,omitempty"`
+	index     int               `yaml:"-"`
+	selected  map[string]int    `yaml:"-"`
+	Responses []Response        `yaml:"responses,omitempty"`
+	Name      string            `yaml:"name,omitempty"`
+	CORS      *CORS             `yaml:"cors,omitempty"`
+	Operation string            `yaml:"operation,omitempty"`
+	Start     int               `yaml:"start,omitempty"`
+	Selected  map[string]string `yaml:"selected,omitempty"`
+	Events    []Event           `yaml:"events,omitempty"`
+	Repeat    bool              `yaml:"repeat,omitempty"`
+	Cutoff    int               `yaml:"disconnectAfter,omitempty"`
+	Socket    *Socket           `yaml:"websocket,omitempty"`
+	Source    string            `yaml:"-"`
+	fileCORS  *CORS             `yaml:"-"`
+	node      *yaml.Node        `yaml:"-"`
+	position  int               `yaml:"-"`
 }
 
 func (r *Route) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	if req.Method == "OPTIONS" {
+	cors := r.corsPolicy()
+
+	if req.Method == "OPTIONS" && !cors.Disabled {
 		r.handleCORS(w, req)
 		return
 	}
 
+	cors.apply(w, req)
+
 	switch strings.ToLower(r.Type) {
-	case ordinalRouteType:
+	case OrdinalRouteType:
 		r.handleOrdinalRoute(w, req)
 		return
 
-	case variableRouteType:
+	case VariableRouteType:
 		r.handleVariableRoute(w, req)
 		return
 
-	case verbRouteType:
+	case VerbRouteType:
 		r.handleVerbRoute(w, req)
 		return
 
+	case SSERouteType:
+		r.handleSSERoute(w, req)
+		return
+
+	case WebSocketRouteType:
+		r.handleWebSocketRoute(w, req)
+		return
+
 	default:
 		r.handleDefaultRoute(w, req)
 		return
@@ -51,36 +92,28 @@ func (r *Route) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 func (r *Route) handleCORS(w http.ResponseWriter, req *http.Request) {
 	log.Info("responding with cors headers for options request")
 
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "*, Authorization")
-	w.Header().Set("Access-Control-Max-Age", "60")
-	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, post-check=0, pre-check=0")
-	w.Header().Set("Vary", "Accept-Encoding")
-	w.WriteHeader(http.StatusNoContent)
+	r.corsPolicy().preflight(w, req)
 }
 
-func (r *Route) handleDefaultRoute(w http.ResponseWriter, req *http.Request) {
-	var b []byte
-	var err error
-
-	if r.Response.Payload != "" {
-		b, err = ioutil.ReadFile(r.Response.Payload)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(fmt.Sprintf("failed to read payload: %v", err.Error()))) //nolint:errcheck
-			return
-		}
+// corsPolicy returns the CORS policy of the route, falling back to the policy
+// of the config file and then the default policy when neither is configured.
+func (r *Route) corsPolicy() *CORS {
+	if r.CORS != nil {
+		return r.CORS
+	}
+
+	if r.fileCORS != nil {
+		return r.fileCORS
 	}
 
-	w.WriteHeader(r.Response.Code)
-	w.Write(b) //nolint:errcheck
+	return &defaultCORS
 }
 
-func (r *Route) handleOrdinalRoute(w http.ResponseWriter, req *http.Request) {
-	var b []byte
-	var err error
+func (r *Route) handleDefaultRoute(w http.ResponseWriter, req *http.Request) {
+	r.Response.write(w, req)
+}
 
+func (r *Route) handleOrdinalRoute(w http.ResponseWriter, req *http.Request) {
 	i := r.index
 
 	if r.Responses == nil {
@@ -89,17 +122,7 @@ func (r *Route) handleOrdinalRoute(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if r.Responses[i].Payload != "" {
-		b, err = ioutil.ReadFile(r.Responses[i].Payload)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(fmt.Sprintf("failed to read payload: %v", err.Error()))) //nolint:errcheck
-			return
-		}
-	}
-
-	w.WriteHeader(r.Responses[i].Code)
-	w.Write(b) //nolint:errcheck
+	r.Responses[i].write(w, req)
 
 	if i < len(r.Responses)-1 {
 		r.index++
@@ -107,9 +130,6 @@ func (r *Route) handleOrdinalRoute(w http.ResponseWriter, req *http.Request) {
 }
 
 func (r *Route) handleVariableRoute(w http.ResponseWriter, req *http.Request) {
-	var b []byte
-	var err error
-
 	i := r.index
 
 	if r.Responses == nil {
@@ -118,36 +138,19 @@ func (r *Route) handleVariableRoute(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if r.Responses[i].Payload != "" {
-		b, err = ioutil.ReadFile(r.Responses[i].Payload)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(fmt.Sprintf("failed to read payload: %v", err.Error()))) //nolint:errcheck
-			return
-		}
-	}
-
-	w.WriteHeader(r.Responses[i].Code)
-	w.Write(b) //nolint:errcheck
+	r.Responses[i].write(w, req)
 }
 
 func (r *Route) handleVerbRoute(w http.ResponseWriter, req *http.Request) {
+	i, found := r.selected[req.Method]
+	if found {
+		r.Responses[i].write(w, req)
+		return
+	}
+
 	for i := range r.Responses {
 		if strings.ToUpper(r.Responses[i].Verb) == req.Method {
-			var b []byte
-			var err error
-
-			if r.Responses[i].Payload != "" {
-				b, err = ioutil.ReadFile(r.Responses[i].Payload)
-				if err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					w.Write([]byte(fmt.Sprintf("failed to read payload: %v", err.Error()))) //nolint:errcheck
-					return
-				}
-			}
-
-			w.WriteHeader(r.Responses[i].Code)
-			w.Write(b) //nolint:errcheck
+			r.Responses[i].write(w, req)
 			return
 		}
 	}
@@ -156,25 +159,59 @@ func (r *Route) handleVerbRoute(w http.ResponseWriter, req *http.Request) {
 	w.Write([]byte("method not defined in config")) //nolint:errcheck
 }
 
-// Reset returns the internal index of the route to 0
+// Reset returns the internal index of the route to its start, and verb routes
+// to their selected responses, or otherwise the first response defined for each
+// verb
 func (r *Route) Reset() {
 	r.index = 0
+	if r.Start > 0 && r.Start < len(r.Responses) {
+		r.index = r.Start
+	}
+
+	r.selected = nil
+	for verb, id := range r.Selected {
+		for i, resp := range r.Responses {
+			if resp.ID != id || !strings.EqualFold(resp.Verb, verb) {
+				continue
+			}
+
+			if r.selected == nil {
+				r.selected = make(map[string]int)
+			}
+
+			r.selected[strings.ToUpper(verb)] = i
+			break
+		}
+	}
 }
 
 // Set takes an id of the response desired, and sets the route to return the
-// specified response if it exists. It will return an error if it is setting the
-// response is not possible.
+// specified response if it exists. Verb routes return the response for requests
+// made with its verb, leaving the responses to other verbs as they are. It will
+// return an error if it is setting the response is not possible.
 func (r *Route) Set(id string) error {
-	if r.Type != variableRouteType {
-		return fmt.Errorf("invalid route type")
+	typ := strings.ToLower(r.Type)
+	if typ != VariableRouteType && typ != VerbRouteType {
+		return ErrInvalidRouteType
 	}
 
 	for i, v := range r.Responses {
-		if v.ID == id {
-			r.index = i
+		if v.ID != id {
+			continue
+		}
+
+		if typ == VerbRouteType {
+			if r.selected == nil {
+				r.selected = make(map[string]int)
+			}
+
+			r.selected[strings.ToUpper(v.Verb)] = i
 			return nil
 		}
+
+		r.index = i
+		return nil
 	}
 
-	return fmt.Errorf("ID not found")
+	return ErrIDNotFound
 }
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	"github.com/franela/goblin"
@@ -43,6 +44,139 @@ func TestRoute(t *testing.T) {
 
 				Expect(res.StatusCode).To(Equal(http.StatusNoContent))
 			})
+
+			g.It("should include the allowed origin on normal responses", func() {
+				f, _ := ParseFromFile()
+
+				server := httptest.NewServer(f)
+				defer server.Close()
+
+				res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/v1/static"))
+				Expect(err).To(BeNil())
+				defer res.Body.Close()
+
+				Expect(res.Header.Get("Access-Control-Allow-Origin")).To(Equal("*"))
+			})
+
+			g.Describe("Configured", func() {
+				var server *httptest.Server
+
+				g.BeforeEach(func() {
+					os.Setenv("DUTY_CONFIG_FILE", "./duty_cors.yaml")
+					defer os.Unsetenv("DUTY_CONFIG_FILE")
+
+					f, _ := ParseFromFile()
+					server = httptest.NewServer(f)
+				})
+
+				g.AfterEach(func() {
+					server.Close()
+				})
+
+				g.It("should apply the file policy to allowed origins", func() {
+					req, err := http.NewRequest("OPTIONS", fmt.Sprintf("%v%v", server.URL, "/v1/foo"), nil)
+					Expect(err).To(BeNil())
+					req.Header.Set("Origin", "https://app.example.test")
+
+					res, err := http.DefaultClient.Do(req)
+					Expect(err).To(BeNil())
+					defer res.Body.Close()
+
+					Expect(res.Header.Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.test"))
+					Expect(res.Header.Get("Access-Control-Allow-Credentials")).To(Equal("true"))
+					Expect(res.Header.Get("Access-Control-Max-Age")).To(Equal("600"))
+
+					req.Method = "GET"
+
+					res, err = http.DefaultClient.Do(req)
+					Expect(err).To(BeNil())
+					defer res.Body.Close()
+
+					Expect(res.StatusCode).To(Equal(http.StatusOK))
+					Expect(res.Header.Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.test"))
+					Expect(res.Header.Get("Access-Control-Expose-Headers")).To(Equal("X-Request-Id"))
+				})
+
+				g.It("should omit the allowed origin for other origins", func() {
+					req, err := http.NewRequest("GET", fmt.Sprintf("%v%v", server.URL, "/v1/foo"), nil)
+					Expect(err).To(BeNil())
+					req.Header.Set("Origin", "https://evil.example.test")
+
+					res, err := http.DefaultClient.Do(req)
+					Expect(err).To(BeNil())
+					defer res.Body.Close()
+
+					Expect(res.Header.Get("Access-Control-Allow-Origin")).To(Equal(""))
+				})
+
+				g.It("should omit the cors headers of preflights from other origins", func() {
+					req, err := http.NewRequest("OPTIONS", fmt.Sprintf("%v%v", server.URL, "/v1/foo"), nil)
+					Expect(err).To(BeNil())
+					req.Header.Set("Origin", "https://evil.example.test")
+					req.Header.Set("Access-Control-Request-Method", "DELETE")
+
+					res, err := http.DefaultClient.Do(req)
+					Expect(err).To(BeNil())
+					defer res.Body.Close()
+
+					Expect(res.StatusCode).To(Equal(http.StatusNoContent))
+					Expect(res.Header.Get("Access-Control-Allow-Origin")).To(Equal(""))
+					Expect(res.Header.Get("Access-Control-Allow-Methods")).To(Equal(""))
+					Expect(res.Header.Get("Access-Control-Allow-Headers")).To(Equal(""))
+					Expect(res.Header.Get("Access-Control-Allow-Credentials")).To(Equal(""))
+					Expect(res.Header.Get("Access-Control-Max-Age")).To(Equal(""))
+				})
+
+				g.It("should echo the origin when the route policy asks", func() {
+					req, err := http.NewRequest("OPTIONS", fmt.Sprintf("%v%v", server.URL, "/v1/echo"), nil)
+					Expect(err).To(BeNil())
+					req.Header.Set("Origin", "https://other.example.test")
+
+					res, err := http.DefaultClient.Do(req)
+					Expect(err).To(BeNil())
+					defer res.Body.Close()
+
+					Expect(res.Header.Get("Access-Control-Allow-Origin")).To(Equal("https://other.example.test"))
+					Expect(res.Header.Get("Access-Control-Allow-Methods")).To(Equal("GET, POST"))
+					Expect(res.Header.Get("Access-Control-Allow-Credentials")).To(Equal(""))
+				})
+
+				g.It("should echo the origin when allowing credentials from any origin", func() {
+					req, err := http.NewRequest("GET", fmt.Sprintf("%v%v", server.URL, "/v1/credentials"), nil)
+					Expect(err).To(BeNil())
+					req.Header.Set("Origin", "https://other.example.test")
+
+					res, err := http.DefaultClient.Do(req)
+					Expect(err).To(BeNil())
+					defer res.Body.Close()
+
+					Expect(res.Header.Get("Access-Control-Allow-Origin")).To(Equal("https://other.example.test"))
+					Expect(res.Header.Get("Access-Control-Allow-Credentials")).To(Equal("true"))
+					Expect(res.Header.Get("Vary")).To(Equal("Origin"))
+
+					req.Header.Del("Origin")
+
+					res, err = http.DefaultClient.Do(req)
+					Expect(err).To(BeNil())
+					defer res.Body.Close()
+
+					Expect(res.Header.Get("Access-Control-Allow-Origin")).To(Equal("*"))
+					Expect(res.Header.Get("Access-Control-Allow-Credentials")).To(Equal(""))
+				})
+
+				g.It("should not respond with cors headers when disabled", func() {
+					req, err := http.NewRequest("OPTIONS", fmt.Sprintf("%v%v", server.URL, "/v1/disabled"), nil)
+					Expect(err).To(BeNil())
+					req.Header.Set("Origin", "https://app.example.test")
+
+					res, err := http.DefaultClient.Do(req)
+					Expect(err).To(BeNil())
+					defer res.Body.Close()
+
+					Expect(res.StatusCode).To(Equal(http.StatusOK))
+					Expect(res.Header.Get("Access-Control-Allow-Origin")).To(Equal(""))
+				})
+			})
 		})
 
 		g.Describe("Static", func() {
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event represents a server-sent event of the script of an sse route. Its data
+// is given inline or read from a payload file, and it is sent after waiting for
+// its delay. Events without an id are given their position in the script,
+// counting from one, so clients may resume the script when reconnecting.
+type Event struct {
+	Event   string `yaml:"event,omitempty"`
+	ID      string `yaml:"id,omitempty"`
+	Data    string `yaml:"data,omitempty"`
+	Payload string `yaml:"payload,omitempty"`
+	Delay   string `yaml:"delay,omitempty"`
+	Retry   int    `yaml:"retry,omitempty"`
+}
+
+// eventID returns the id of the event at the position in the script
+func (e *Event) eventID(i int) string {
+	if e.ID != "" {
+		return e.ID
+	}
+
+	return strconv.Itoa(i + 1)
+}
+
+func (e *Event) data() ([]byte, error) {
+	if e.Payload == "" {
+		return []byte(e.Data), nil
+	}
+
+	b, err := ioutil.ReadFile(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload: %v", err.Error())
+	}
+
+	return b, nil
+}
+
+// write writes the event in the event stream format, giving each line of its
+// data its own data field
+func (e *Event) write(w http.ResponseWriter, id string, data []byte) error {
+	var b strings.Builder
+
+	if e.Event != "" {
+		fmt.Fprintf(&b, "event: %v\n", e.Event)
+	}
+
+	fmt.Fprintf(&b, "id: %v\n", id)
+
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %v\n", e.Retry)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(strings.Replace(string(data), "\r\n", "\n", -1), "\n"), "\n")
+	for _, l := range lines {
+		fmt.Fprintf(&b, "data: %v\n", l)
+	}
+
+	b.WriteString("\n")
+
+	_, err := w.Write([]byte(b.String()))
+
+	return err
+}
+
+// handleSSERoute streams the events of the script of the route, resuming after
+// the event named by the Last-Event-ID of a reconnecting client. The stream is
+// ended once the script finishes, unless it repeats, or the connection dropped
+// once the number of events to disconnect after have been sent. Clients resuming
+// after the end of a script that does not repeat are told to stop reconnecting
+// with a 204.
+func (r *Route) handleSSERoute(w http.ResponseWriter, req *http.Request) {
+	if len(r.Events) == 0 {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("no events specified for sse endpoint")) //nolint:errcheck
+		return
+	}
+
+	next := 0
+	if last := req.Header.Get("Last-Event-ID"); last != "" {
+		for i := range r.Events {
+			if r.Events[i].eventID(i) == last {
+				next = i + 1
+				break
+			}
+		}
+	}
+
+	if next >= len(r.Events) {
+		if !r.Repeat {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next = 0
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	for sent := 0; r.Cutoff == 0 || sent < r.Cutoff; sent++ {
+		if next >= len(r.Events) {
+			if !r.Repeat {
+				return
+			}
+
+			next = 0
+		}
+
+		e := &r.Events[next]
+
+		d, _ := time.ParseDuration(e.Delay)
+		select {
+		case <-req.Context().Done():
+			return
+
+		case <-time.After(d):
+		}
+
+		data, err := e.data()
+		if err != nil {
+			log.Errorf("failed to send event %v: %v", e.eventID(next), err.Error())
+			return
+		}
+
+		err = e.write(w, e.eventID(next), data)
+		if err != nil {
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		next++
+	}
+
+	if r.Cutoff > 0 {
+		abort(req, "disconnect")
+	}
+}
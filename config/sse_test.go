@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/ledger"
+	"github.com/gomicro/penname"
+	. "github.com/onsi/gomega"
+)
+
+const sseConfig = `
+routes:
+  - endpoint: "/v1/events"
+    type: "sse"
+    events:
+      - event: "greeting"
+        data: "hello"
+      - data: "first line\nsecond line"
+        delay: "10ms"
+      - event: "done"
+        id: "last"
+        data: "bye"
+        retry: 500
+
+  - endpoint: "/v1/ticker"
+    type: "sse"
+    repeat: true
+    disconnectAfter: 4
+    events:
+      - data: "tick"
+      - data: "tock"
+`
+
+func TestSSE(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Server-Sent Events", func() {
+		var server *httptest.Server
+
+		g.Before(func() {
+			mw := penname.New()
+			log = ledger.New(mw, ledger.DebugLevel)
+
+			f, err := Parse([]byte(sseConfig))
+			Expect(err).To(BeNil())
+
+			server = httptest.NewServer(f)
+		})
+
+		g.After(func() {
+			server.Close()
+		})
+
+		read := func(path, lastID string) (*http.Response, string, error) {
+			req, err := http.NewRequest("GET", fmt.Sprintf("%v%v", server.URL, path), nil)
+			Expect(err).To(BeNil())
+
+			if lastID != "" {
+				req.Header.Set("Last-Event-ID", lastID)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			b, err := ioutil.ReadAll(res.Body)
+
+			return res, string(b), err
+		}
+
+		stream := func(path, lastID string) (*http.Response, string) {
+			res, body, err := read(path, lastID)
+			Expect(err).To(BeNil())
+
+			return res, body
+		}
+
+		g.It("should stream the script of events", func() {
+			res, body := stream("/v1/events", "")
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+			Expect(body).To(Equal(strings.Join([]string{
+				"event: greeting\nid: 1\ndata: hello\n",
+				"id: 2\ndata: first line\ndata: second line\n",
+				"event: done\nid: last\nretry: 500\ndata: bye\n",
+				"",
+			}, "\n")))
+		})
+
+		g.It("should resume after the last event id", func() {
+			_, body := stream("/v1/events", "2")
+			Expect(body).To(Equal("event: done\nid: last\nretry: 500\ndata: bye\n\n"))
+		})
+
+		g.It("should stop reconnecting clients once the script finished", func() {
+			res, body := stream("/v1/events", "last")
+			Expect(res.StatusCode).To(Equal(http.StatusNoContent))
+			Expect(body).To(Equal(""))
+		})
+
+		g.It("should repeat the script and disconnect after the given events", func() {
+			_, body, err := read("/v1/ticker", "")
+			Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+			Expect(body).To(Equal("id: 1\ndata: tick\n\nid: 2\ndata: tock\n\nid: 1\ndata: tick\n\nid: 2\ndata: tock\n\n"))
+
+			_, body, err = read("/v1/ticker", "1")
+			Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+			Expect(strings.Count(body, "data:")).To(Equal(4))
+			Expect(body).To(HavePrefix("id: 2\ndata: tock\n\n"))
+		})
+
+		g.It("should refuse invalid scripts", func() {
+			_, err := Parse([]byte(`
+routes:
+  - endpoint: "/v1/empty"
+    type: "sse"
+  - endpoint: "/v1/bad"
+    type: "sse"
+    events:
+      - id: "2"
+        data: "a"
+        delay: "later"
+      - data: "b"
+`))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("routes[0].events: events are required for sse routes"))
+			Expect(err.Error()).To(ContainSubstring(`routes[1].events[0].delay: invalid delay "later"`))
+			Expect(err.Error()).To(ContainSubstring("routes[1].events[1].id: duplicate event id 2, also used by events[0]"))
+		})
+	})
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	defaultChunkSize = 1024
+	rateInterval     = 100 * time.Millisecond
+)
+
+// Stream represents how the body of a response is dripped to the client
+// rather than written at once. The body is written in chunks of the given
+// size, flushed as each is written, with a delay between them, and held to the
+// given bandwidth in bytes per second.
+type Stream struct {
+	ChunkSize int    `yaml:"chunkSize,omitempty"`
+	Delay     string `yaml:"delay,omitempty"`
+	Rate      int    `yaml:"bytesPerSecond,omitempty"`
+}
+
+// chunkSize returns the size of the chunks to write, defaulting to a tenth of
+// a second of the bandwidth when capped
+func (s *Stream) chunkSize() int {
+	if s.ChunkSize > 0 {
+		return s.ChunkSize
+	}
+
+	if s.Rate > 0 {
+		n := int(int64(s.Rate) * int64(rateInterval) / int64(time.Second))
+		if n > 0 {
+			return n
+		}
+
+		return 1
+	}
+
+	return defaultChunkSize
+}
+
+// pause returns how long to wait after writing a chunk of the given size,
+// the longer of the delay and the time the chunk takes at the bandwidth
+func (s *Stream) pause(n int) time.Duration {
+	d, _ := time.ParseDuration(s.Delay)
+
+	if s.Rate > 0 {
+		r := time.Duration(int64(n) * int64(time.Second) / int64(s.Rate))
+		if r > d {
+			d = r
+		}
+	}
+
+	return d
+}
+
+// write streams the body to the client with the status code, stopping early
+// when the client goes away
+func (s *Stream) write(w http.ResponseWriter, req *http.Request, code int, b []byte) {
+	w.Header().Del("Content-Length")
+	w.WriteHeader(code)
+
+	flusher, _ := w.(http.Flusher)
+	size := s.chunkSize()
+
+	for len(b) > 0 {
+		n := size
+		if n > len(b) {
+			n = len(b)
+		}
+
+		_, err := w.Write(b[:n])
+		if err != nil {
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		b = b[n:]
+		if len(b) == 0 {
+			return
+		}
+
+		select {
+		case <-req.Context().Done():
+			return
+
+		case <-time.After(s.pause(n)):
+		}
+	}
+}
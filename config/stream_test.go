@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/ledger"
+	"github.com/gomicro/penname"
+	. "github.com/onsi/gomega"
+)
+
+const streamConfig = `
+routes:
+  - endpoint: "/v1/chunks"
+    response:
+      code: 200
+      body: "0123456789"
+      stream:
+        chunkSize: 4
+        delay: "20ms"
+
+  - endpoint: "/v1/capped"
+    response:
+      code: 200
+      body: "012345678901234567890123456789"
+      stream:
+        bytesPerSecond: 100
+`
+
+func TestStream(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Streaming", func() {
+		var server *httptest.Server
+
+		g.Before(func() {
+			mw := penname.New()
+			log = ledger.New(mw, ledger.DebugLevel)
+
+			f, err := Parse([]byte(streamConfig))
+			Expect(err).To(BeNil())
+
+			server = httptest.NewServer(f)
+		})
+
+		g.After(func() {
+			server.Close()
+		})
+
+		// reads returns each read of the body as it arrives, along with how long
+		// the whole body took
+		reads := func(path string) (*http.Response, []string, time.Duration) {
+			start := time.Now()
+
+			res, err := http.Get(fmt.Sprintf("%v%v", server.URL, path))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			var chunks []string
+			buf := make([]byte, 64)
+			for {
+				n, err := res.Body.Read(buf)
+				if n > 0 {
+					chunks = append(chunks, string(buf[:n]))
+				}
+
+				if err != nil {
+					break
+				}
+			}
+
+			return res, chunks, time.Since(start)
+		}
+
+		g.It("should write the body in chunks with a delay between them", func() {
+			res, chunks, took := reads("/v1/chunks")
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.TransferEncoding).To(Equal([]string{"chunked"}))
+			Expect(chunks).To(Equal([]string{"0123", "4567", "89"}))
+			Expect(took).To(BeNumerically(">=", 40*time.Millisecond))
+		})
+
+		g.It("should hold the body to the bandwidth", func() {
+			_, chunks, took := reads("/v1/capped")
+			Expect(chunks).To(HaveLen(3))
+			Expect(chunks[0]).To(Equal("0123456789"))
+			Expect(took).To(BeNumerically(">=", 200*time.Millisecond))
+
+			s := &Stream{Rate: 100, Delay: "1s"}
+			Expect(s.pause(10)).To(Equal(time.Second))
+		})
+
+		g.It("should refuse invalid stream options", func() {
+			_, err := Parse([]byte(`
+routes:
+  - endpoint: "/v1/foo"
+    response:
+      code: 200
+      stream:
+        chunkSize: -1
+        delay: "soon"
+`))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("routes[0].response.stream.chunkSize: chunkSize must not be negative"))
+			Expect(err.Error()).To(ContainSubstring(`routes[0].response.stream.delay: invalid delay "soon"`))
+		})
+	})
+}
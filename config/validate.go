@@ -0,0 +1,551 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomicro/ledger"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	routeTypes = []string{"", StaticRouteType, OrdinalRouteType, VariableRouteType, VerbRouteType, SSERouteType, WebSocketRouteType}
+)
+
+// Problem represents an issue found when validating a config, along with the
+// path of the offending option and, when read from a file, its location
+type Problem struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string
+	Message string
+	path    []interface{}
+}
+
+// ValidationError represents every problem found when validating a config
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		if p.File != "" {
+			return fmt.Sprintf("%v: %v: %v", p.File, p.Path, p.Message)
+		}
+
+		return fmt.Sprintf("%v: %v", p.Path, p.Message)
+	}
+
+	loc := fmt.Sprintf("%v:%v", p.Line, p.Column)
+	if p.File != "" {
+		loc = fmt.Sprintf("%v:%v", p.File, loc)
+	}
+
+	if p.Path == "" {
+		return fmt.Sprintf("%v: %v", loc, p.Message)
+	}
+
+	return fmt.Sprintf("%v: %v: %v", loc, p.Path, p.Message)
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = p.String()
+	}
+
+	return fmt.Sprintf("invalid config:\n%v", strings.Join(lines, "\n"))
+}
+
+// Validate checks the routes and hosts of the File for options that would be
+// ignored or could not be served, returning every problem found.
+func (f *File) Validate() []Problem {
+	var problems []Problem
+
+	add := func(msg string, path ...interface{}) {
+		problems = append(problems, Problem{
+			Path:    formatPath(path),
+			Message: msg,
+			path:    path,
+		})
+	}
+
+	endpoints := make(map[string]int)
+	names := make(map[string]int)
+
+	for i, r := range f.Routes {
+		switch {
+		case r.Endpoint == "":
+			add("endpoint is required", "routes", i)
+
+		case !strings.HasPrefix(r.Endpoint, "/") && r.Endpoint != catchAllEndpoint:
+			add("endpoint must begin with a slash", "routes", i, "endpoint")
+
+		case !validParams(r.Endpoint):
+			add("endpoint has an invalid path parameter", "routes", i, "endpoint")
+
+		default:
+			key := routeKey(r.Host, endpointShape(r.Endpoint))
+			first, dup := endpoints[key]
+			if dup {
+				add(fmt.Sprintf("duplicate endpoint %v, also defined by %v", r.Host+r.Endpoint, f.routeRef(first, r.Source)), "routes", i, "endpoint")
+			} else {
+				endpoints[key] = i
+			}
+		}
+
+		if r.Operation != "" && (f.OpenAPI == nil || f.OpenAPI.Spec == "") {
+			add("operation requires an openapi spec", "routes", i, "operation")
+		}
+
+		if r.Name != "" {
+			first, dup := names[r.Name]
+			if dup {
+				add(fmt.Sprintf("duplicate route name %v, also used by %v", r.Name, f.routeRef(first, r.Source)), "routes", i, "name")
+			} else {
+				names[r.Name] = i
+			}
+		}
+
+		if len(grpcResponses(&f.Routes[i])) > 0 && (f.GRPC == nil || len(f.GRPC.Descriptors) == 0) {
+			add("grpc responses require grpc descriptors", "routes", i)
+		}
+
+		typ := strings.ToLower(r.Type)
+		if !containsType(typ) {
+			add(fmt.Sprintf("unknown route type %q", r.Type), "routes", i, "type")
+			continue
+		}
+
+		if r.Start != 0 && typ != OrdinalRouteType && typ != VariableRouteType {
+			add("start requires an ordinal or variable route", "routes", i, "start")
+		}
+
+		if len(r.Selected) > 0 && typ != VerbRouteType {
+			add("selected requires a verb route", "routes", i, "selected")
+		}
+
+		if len(r.Events) > 0 && typ != SSERouteType {
+			add("events require an sse route", "routes", i, "events")
+		}
+
+		if r.Socket != nil && typ != WebSocketRouteType {
+			add("websocket requires a websocket route", "routes", i, "websocket")
+		}
+
+		if typ == SSERouteType {
+			validateEvents(r, add, i)
+			continue
+		}
+
+		if typ == WebSocketRouteType {
+			validateSocket(r.Socket, add, i)
+			continue
+		}
+
+		if typ == "" || typ == StaticRouteType {
+			validateResponse(r.Response, add, "routes", i, "response")
+			continue
+		}
+
+		if len(r.Responses) == 0 {
+			add(fmt.Sprintf("responses are required for %v routes", typ), "routes", i, "responses")
+			continue
+		}
+
+		if typ == VariableRouteType && r.Name == "" {
+			add("name is required for variable routes", "routes", i, "name")
+		}
+
+		ids := make(map[string]int)
+		for j, resp := range r.Responses {
+			validateResponse(resp, add, "routes", i, "responses", j)
+
+			if typ == VerbRouteType {
+				if resp.Verb == "" {
+					add("verb is required for verb routes", "routes", i, "responses", j, "verb")
+				} else if !validMethod(resp.Verb) {
+					add(fmt.Sprintf("unknown verb %q", resp.Verb), "routes", i, "responses", j, "verb")
+				}
+			}
+
+			if resp.ID == "" {
+				continue
+			}
+
+			first, dup := ids[resp.ID]
+			if dup {
+				add(fmt.Sprintf("duplicate response id %v, also used by responses[%v]", resp.ID, first), "routes", i, "responses", j, "id")
+			} else {
+				ids[resp.ID] = j
+			}
+		}
+
+		if (typ == OrdinalRouteType || typ == VariableRouteType) && (r.Start < 0 || r.Start >= len(r.Responses)) {
+			add(fmt.Sprintf("start must be between 0 and %v", len(r.Responses)-1), "routes", i, "start")
+		}
+
+		if typ == VerbRouteType {
+			for _, verb := range sortedVerbs(r.Selected) {
+				if !verbHasID(r.Responses, verb, r.Selected[verb]) {
+					add(fmt.Sprintf("no %v response has id %v", strings.ToUpper(verb), r.Selected[verb]), "routes", i, "selected", verb)
+				}
+			}
+		}
+	}
+
+	for i, h := range f.Hosts {
+		if h.Host == "" {
+			add("host is required", "hosts", i)
+		}
+	}
+
+	if f.Log.Level != "" && !validLevel(f.Log.Level) {
+		add(fmt.Sprintf("unknown log level %q", f.Log.Level), "log", "level")
+	}
+
+	if f.OpenAPI != nil {
+		if f.OpenAPI.Spec == "" {
+			add("spec is required", "openapi")
+		}
+
+		if f.OpenAPI.Violation != nil && f.OpenAPI.Violation.Code != 0 {
+			validateCode(f.OpenAPI.Violation.Code, add, "openapi", "violation", "code")
+		}
+	}
+
+	if f.GRPC != nil && len(f.GRPC.Descriptors) == 0 {
+		add("descriptors are required", "grpc")
+	}
+
+	return problems
+}
+
+// validLevel reports whether the level is one of the levels of the ledger
+// logger
+func validLevel(level string) bool {
+	for _, l := range []ledger.Level{ledger.FatalLevel, ledger.ErrorLevel, ledger.WarnLevel, ledger.InfoLevel, ledger.DebugLevel} {
+		if strings.EqualFold(l.String(), level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verbHasID reports whether a response for the verb has the id
+func verbHasID(responses []Response, verb, id string) bool {
+	for _, resp := range responses {
+		if resp.ID == id && strings.EqualFold(resp.Verb, verb) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedVerbs(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// routeRef refers to the route at the index as it is written in its own file,
+// naming that file when it is not the given source.
+func (f *File) routeRef(i int, source string) string {
+	r := f.Routes[i]
+	if r.node == nil {
+		return fmt.Sprintf("routes[%v]", i)
+	}
+
+	ref := fmt.Sprintf("routes[%v]", r.position)
+	if r.Source != source {
+		ref = fmt.Sprintf("%v in %v", ref, r.Source)
+	}
+
+	return ref
+}
+
+// validParams reports whether every path parameter of the endpoint is a whole,
+// named segment used only once
+func validParams(endpoint string) bool {
+	names := make(map[string]bool)
+
+	for _, seg := range strings.Split(endpoint, "/") {
+		name, ok := paramName(seg)
+		if !ok {
+			if strings.ContainsAny(seg, "{}") {
+				return false
+			}
+
+			continue
+		}
+
+		if name == "" || strings.ContainsAny(name, "{}") || names[name] {
+			return false
+		}
+
+		names[name] = true
+	}
+
+	return true
+}
+
+// validateEvents checks the script of the sse route at the index
+func validateEvents(r Route, add func(string, ...interface{}), i int) {
+	if len(r.Events) == 0 {
+		add("events are required for sse routes", "routes", i, "events")
+	}
+
+	if r.Cutoff < 0 {
+		add("disconnectAfter must not be negative", "routes", i, "disconnectAfter")
+	}
+
+	ids := make(map[string]int)
+	for j, e := range r.Events {
+		if e.Data != "" && e.Payload != "" {
+			add("only one of data or payload may be given", "routes", i, "events", j)
+		}
+
+		if e.Delay != "" {
+			d, err := time.ParseDuration(e.Delay)
+			if err != nil || d < 0 {
+				add(fmt.Sprintf("invalid delay %q", e.Delay), "routes", i, "events", j, "delay")
+			}
+		}
+
+		id := e.eventID(j)
+		first, dup := ids[id]
+		if dup {
+			add(fmt.Sprintf("duplicate event id %v, also used by events[%v]", id, first), "routes", i, "events", j, "id")
+		} else {
+			ids[id] = j
+		}
+	}
+}
+
+// validateSocket checks the script of the websocket route at the index
+func validateSocket(s *Socket, add func(string, ...interface{}), i int) {
+	if s == nil {
+		add("websocket is required for websocket routes", "routes", i)
+		return
+	}
+
+	validateDuration(s.Ping, add, "routes", i, "websocket", "ping")
+	validateClosing(s.Close, add, "routes", i, "websocket", "close")
+	validateFrames(s.OnConnect, add, "routes", i, "websocket", "onConnect")
+
+	for j, r := range s.Replies {
+		if r.Text != "" {
+			_, err := regexp.Compile(r.Text)
+			if err != nil {
+				add(fmt.Sprintf("invalid text pattern: %v", err.Error()), "routes", i, "websocket", "replies", j, "text")
+			}
+		}
+
+		validateFrames(r.Frames, add, "routes", i, "websocket", "replies", j, "frames")
+		validateClosing(r.Close, add, "routes", i, "websocket", "replies", j, "close")
+	}
+}
+
+func validateFrames(frames []Frame, add func(string, ...interface{}), path ...interface{}) {
+	for j, f := range frames {
+		at := append(append([]interface{}{}, path...), j)
+
+		if f.Text != "" && f.Payload != "" {
+			add("only one of text or payload may be given", at...)
+		}
+
+		validateDuration(f.Delay, add, append(at, "delay")...)
+	}
+}
+
+func validateClosing(c *Closing, add func(string, ...interface{}), path ...interface{}) {
+	if c == nil {
+		return
+	}
+
+	at := func(option string) []interface{} {
+		return append(append([]interface{}{}, path...), option)
+	}
+
+	if c.Code != 0 && (c.Code < 1000 || c.Code > 4999 || c.Code == 1005 || c.Code == 1006 || c.Code == 1015) {
+		add(fmt.Sprintf("invalid close code %v", c.Code), at("code")...)
+	}
+
+	validateDuration(c.After, add, at("after")...)
+}
+
+// validateDuration checks the option, when given, is a duration that is not
+// negative
+func validateDuration(v string, add func(string, ...interface{}), path ...interface{}) {
+	if v == "" {
+		return
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		add(fmt.Sprintf("invalid duration %q", v), path...)
+	}
+}
+
+// validateResponse checks the status code of the response and any date it
+// declares as its modification time. gRPC replies are always sent with a 200,
+// so their status code may be left unset.
+func validateResponse(resp Response, add func(string, ...interface{}), path ...interface{}) {
+	at := func(option string) []interface{} {
+		return append(append([]interface{}{}, path...), option)
+	}
+
+	if resp.GRPC == nil || resp.Code != 0 {
+		validateCode(resp.Code, add, at("code")...)
+	}
+
+	if resp.GRPC != nil {
+		_, ok := grpcCode(resp.GRPC.Status)
+		if !ok {
+			add(fmt.Sprintf("unknown gRPC status %q", resp.GRPC.Status), append(at("grpc"), "status")...)
+		}
+
+		validateDuration(resp.GRPC.Delay, add, append(at("grpc"), "delay")...)
+	}
+
+	if resp.Modified != "" && !strings.EqualFold(resp.Modified, autoValidator) {
+		_, err := parseDate(resp.Modified)
+		if err != nil {
+			add("lastModified must be auto or a date", at("lastModified")...)
+		}
+	}
+
+	if !containsRangeMode(strings.ToLower(resp.Ranges)) {
+		add(fmt.Sprintf("unknown ranges mode %q", resp.Ranges), at("ranges")...)
+	}
+
+	if resp.Stream == nil {
+		return
+	}
+
+	if resp.Stream.ChunkSize < 0 {
+		add("chunkSize must not be negative", append(at("stream"), "chunkSize")...)
+	}
+
+	if resp.Stream.Delay != "" {
+		d, err := time.ParseDuration(resp.Stream.Delay)
+		if err != nil || d < 0 {
+			add(fmt.Sprintf("invalid delay %q", resp.Stream.Delay), append(at("stream"), "delay")...)
+		}
+	}
+
+	if resp.Stream.Rate < 0 {
+		add("bytesPerSecond must not be negative", append(at("stream"), "bytesPerSecond")...)
+	}
+}
+
+func validateCode(code int, add func(string, ...interface{}), path ...interface{}) {
+	if code < 100 || code > 599 {
+		add(fmt.Sprintf("invalid status code %v", code), path...)
+	}
+}
+
+func containsType(typ string) bool {
+	for _, t := range routeTypes {
+		if t == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
+// locate sets the file, line and column of each problem from the position of
+// the node at its path, or the closest parent node present when the option
+// itself is missing.
+func locate(problems []Problem, root *yaml.Node, file string) {
+	for i := range problems {
+		locateAt(&problems[i], root, problems[i].path, file)
+	}
+}
+
+// locateAt sets the location of the problem from the node at the path beneath
+// the given node.
+func locateAt(p *Problem, n *yaml.Node, path []interface{}, file string) {
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+
+	for _, seg := range path {
+		next := child(n, seg)
+		if next == nil {
+			break
+		}
+
+		n = next
+	}
+
+	p.File = file
+	p.Line = n.Line
+	p.Column = n.Column
+}
+
+func child(n *yaml.Node, seg interface{}) *yaml.Node {
+	switch s := seg.(type) {
+	case string:
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == s {
+				return n.Content[i+1]
+			}
+		}
+
+	case int:
+		if n.Kind == yaml.SequenceNode && s < len(n.Content) {
+			return n.Content[s]
+		}
+	}
+
+	return nil
+}
+
+func formatPath(path []interface{}) string {
+	var b strings.Builder
+
+	for _, seg := range path {
+		switch s := seg.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%v]", s)
+
+		default:
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+
+			fmt.Fprintf(&b, "%v", s)
+		}
+	}
+
+	return b.String()
+}
+
+// validMethod reports whether the verb is a method duty can match requests
+// against
+func validMethod(verb string) bool {
+	switch strings.ToUpper(verb) {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestValidate(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Validation", func() {
+		g.It("should accept a valid config file", func() {
+			Expect(ValidateFile("./duty.yaml")).To(BeNil())
+		})
+
+		g.It("should locate every problem in an invalid config file", func() {
+			err := ValidateFile("./duty_invalid.yaml")
+			Expect(err).NotTo(BeNil())
+
+			var verr *ValidationError
+			Expect(errors.As(err, &verr)).To(BeTrue())
+
+			var lines []string
+			for _, p := range verr.Problems {
+				lines = append(lines, p.String())
+			}
+
+			Expect(lines).To(Equal([]string{
+				`./duty_invalid.yaml:7:15: routes[1].endpoint: duplicate endpoint /v1/foo, also defined by routes[0]`,
+				`./duty_invalid.yaml:8:11: routes[1].type: unknown route type "ordnial"`,
+				`./duty_invalid.yaml:12:5: routes[2].responses: responses are required for ordinal routes`,
+				`./duty_invalid.yaml:15:5: routes[3].name: name is required for variable routes`,
+				`./duty_invalid.yaml:21:13: routes[3].responses[1].id: duplicate response id ok, also used by responses[0]`,
+				`./duty_invalid.yaml:23:15: routes[4].endpoint: endpoint has an invalid path parameter`,
+				`./duty_invalid.yaml:31:15: routes[6].endpoint: duplicate endpoint /v1/items/{sku}, also defined by routes[5]`,
+				`./duty_invalid.yaml:36:10: log.level: unknown log level "verbose"`,
+			}))
+		})
+
+		g.It("should locate unknown options", func() {
+			_, err := Parse([]byte(`
+routes:
+  - endpoint: "/v1/foo"
+    type: "ordinal"
+    responses:
+      - code: 200
+    respones:
+      - code: 200
+  - endpoint: "/v1/bar"
+    response:
+      code: 200
+      paylod: "foo.json"
+      headers:
+        X-Paylod: "kept"
+log:
+  levle: "debug"
+`))
+			Expect(err).NotTo(BeNil())
+
+			var verr *ValidationError
+			Expect(errors.As(err, &verr)).To(BeTrue())
+
+			var lines []string
+			for _, p := range verr.Problems {
+				lines = append(lines, p.String())
+			}
+
+			Expect(lines).To(Equal([]string{
+				`7:5: routes[0].respones: unknown option respones`,
+				`12:7: routes[1].response.paylod: unknown option paylod`,
+				`16:3: log.levle: unknown option levle`,
+			}))
+		})
+
+		g.It("should refuse to parse an invalid config", func() {
+			_, err := ParseFile("./duty_invalid.yaml")
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("invalid config"))
+		})
+	})
+}
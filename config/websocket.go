@@ -0,0 +1,344 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	upgrader = websocket.Upgrader{
+		CheckOrigin: func(*http.Request) bool { return true },
+	}
+)
+
+// Socket represents the script run on the connections of a websocket route.
+// Frames are sent once connected, incoming messages are answered by the first
+// reply matching them, pings are sent on an interval, and the connection may
+// be closed after a while with a given code.
+type Socket struct {
+	OnConnect []Frame  `yaml:"onConnect,omitempty"`
+	Replies   []Reply  `yaml:"replies,omitempty"`
+	Ping      string   `yaml:"ping,omitempty"`
+	Close     *Closing `yaml:"close,omitempty"`
+}
+
+// Frame represents a message sent to the client, given inline as text or read
+// from a payload file, and sent after waiting for its delay. Frames are sent
+// as text unless binary is set.
+type Frame struct {
+	Text    string `yaml:"text,omitempty"`
+	Payload string `yaml:"payload,omitempty"`
+	Binary  bool   `yaml:"binary,omitempty"`
+	Delay   string `yaml:"delay,omitempty"`
+}
+
+// Reply represents frames sent in answer to incoming messages that match its
+// text pattern, a regular expression, or its JSON pattern, which matches JSON
+// messages holding at least the given fields and values. Replies without a
+// pattern match any message. The connection is closed after replying when
+// close is given.
+type Reply struct {
+	Text   string      `yaml:"text,omitempty"`
+	JSON   interface{} `yaml:"json,omitempty"`
+	Frames []Frame     `yaml:"frames,omitempty"`
+	Close  *Closing    `yaml:"close,omitempty"`
+}
+
+// Closing represents how a connection is closed, with the close code and
+// reason sent to the client, after the given delay
+type Closing struct {
+	Code   int    `yaml:"code,omitempty"`
+	Reason string `yaml:"reason,omitempty"`
+	After  string `yaml:"after,omitempty"`
+}
+
+// frames returns every frame of the script
+func (s *Socket) frames() []*Frame {
+	if s == nil {
+		return nil
+	}
+
+	var frames []*Frame
+	for i := range s.OnConnect {
+		frames = append(frames, &s.OnConnect[i])
+	}
+
+	for i := range s.Replies {
+		for j := range s.Replies[i].Frames {
+			frames = append(frames, &s.Replies[i].Frames[j])
+		}
+	}
+
+	return frames
+}
+
+// socketConn serializes the writes of a script to a connection
+type socketConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *socketConn) send(f *Frame) error {
+	b, err := f.data()
+	if err != nil {
+		return err
+	}
+
+	typ := websocket.TextMessage
+	if f.Binary {
+		typ = websocket.BinaryMessage
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.WriteMessage(typ, b)
+}
+
+func (c *socketConn) close(cl *Closing) {
+	code := websocket.CloseNormalClosure
+	if cl.Code != 0 {
+		code = cl.Code
+	}
+
+	msg := websocket.FormatCloseMessage(code, cl.Reason)
+	c.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second)) //nolint:errcheck
+}
+
+func (f *Frame) data() ([]byte, error) {
+	if f.Payload == "" {
+		return []byte(f.Text), nil
+	}
+
+	b, err := ioutil.ReadFile(f.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload: %v", err.Error())
+	}
+
+	return b, nil
+}
+
+// matches reports whether the incoming message satisfies the patterns of the
+// reply
+func (r *Reply) matches(msg []byte) bool {
+	if r.Text != "" {
+		matched, err := regexp.Match(r.Text, msg)
+		if err != nil || !matched {
+			return false
+		}
+	}
+
+	if r.JSON != nil {
+		var v interface{}
+		err := json.Unmarshal(msg, &v)
+		if err != nil || !containsJSON(v, r.JSON) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsJSON reports whether the value holds the pattern, with objects
+// holding at least the fields of the pattern object and arrays and scalars
+// equal to the pattern, scalars being of the same type
+func containsJSON(v, pattern interface{}) bool {
+	switch p := pattern.(type) {
+	case map[string]interface{}:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		for k, pv := range p {
+			val, ok := obj[k]
+			if !ok || !containsJSON(val, pv) {
+				return false
+			}
+		}
+
+		return true
+
+	case []interface{}:
+		arr, ok := v.([]interface{})
+		if !ok || len(arr) != len(p) {
+			return false
+		}
+
+		for i := range p {
+			if !containsJSON(arr[i], p[i]) {
+				return false
+			}
+		}
+
+		return true
+
+	case nil:
+		return v == nil
+	}
+
+	if v == nil {
+		return false
+	}
+
+	if reflect.TypeOf(v).Kind() == reflect.Map || reflect.TypeOf(v).Kind() == reflect.Slice {
+		return false
+	}
+
+	return jsonScalar(v) == jsonScalar(pattern)
+}
+
+// jsonScalar returns the scalar with its number as a float64, as JSON numbers
+// are decoded, so that numbers read from YAML may be compared with it
+func jsonScalar(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	}
+
+	return v
+}
+
+// handleWebSocketRoute upgrades the connection and runs the script of the
+// route until either side closes it
+func (r *Route) handleWebSocketRoute(w http.ResponseWriter, req *http.Request) {
+	if r.Socket == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("no script specified for websocket endpoint")) //nolint:errcheck
+		return
+	}
+
+	ws, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		log.Warnf("failed to upgrade websocket connection: %v", err.Error())
+		return
+	}
+
+	conn := &socketConn{Conn: ws}
+	defer conn.Close()
+
+	messages := make(chan []byte)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			select {
+			case messages <- msg:
+			case <-req.Context().Done():
+				return
+			}
+		}
+	}()
+
+	s := r.Socket
+
+	var closeAfter <-chan time.Time
+	if s.Close != nil {
+		d, _ := time.ParseDuration(s.Close.After)
+		closeAfter = time.After(d)
+	}
+
+	var ping <-chan time.Time
+	if d, _ := time.ParseDuration(s.Ping); d > 0 {
+		t := time.NewTicker(d)
+		defer t.Stop()
+
+		ping = t.C
+	}
+
+	if !r.sendFrames(conn, s.OnConnect, done) {
+		return
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case <-closeAfter:
+			conn.close(s.Close)
+			return
+
+		case <-ping:
+			conn.mu.Lock()
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second))
+			conn.mu.Unlock()
+
+			if err != nil {
+				return
+			}
+
+		case msg := <-messages:
+			for i := range s.Replies {
+				reply := &s.Replies[i]
+				if !reply.matches(msg) {
+					continue
+				}
+
+				if !r.sendFrames(conn, reply.Frames, done) {
+					return
+				}
+
+				if reply.Close != nil {
+					d, _ := time.ParseDuration(reply.Close.After)
+					select {
+					case <-done:
+						return
+
+					case <-time.After(d):
+					}
+
+					conn.close(reply.Close)
+					return
+				}
+
+				break
+			}
+		}
+	}
+}
+
+// sendFrames sends the frames in order after their delays, reporting whether
+// the connection is still open
+func (r *Route) sendFrames(conn *socketConn, frames []Frame, done <-chan struct{}) bool {
+	for i := range frames {
+		f := &frames[i]
+
+		d, _ := time.ParseDuration(f.Delay)
+		select {
+		case <-done:
+			return false
+
+		case <-time.After(d):
+		}
+
+		err := conn.send(f)
+		if err != nil {
+			log.Errorf("failed to send websocket frame on %v: %v", r.Endpoint, err.Error())
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/ledger"
+	"github.com/gomicro/penname"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/gomega"
+)
+
+const websocketConfig = `
+routes:
+  - endpoint: "/v1/ws"
+    type: "websocket"
+    websocket:
+      onConnect:
+        - text: '{"type": "welcome"}'
+        - payload: "foo.json"
+          binary: true
+      replies:
+        - json:
+            type: "subscribe"
+            channels: ["prices"]
+          frames:
+            - text: '{"type": "subscribed"}'
+        - text: "^echo"
+          frames:
+            - text: "echoed"
+              delay: "10ms"
+        - text: "^bye$"
+          close:
+            code: 4001
+            reason: "asked to leave"
+
+  - endpoint: "/v1/pinger"
+    type: "websocket"
+    websocket:
+      ping: "20ms"
+      close:
+        code: 1001
+        after: "100ms"
+`
+
+func TestWebSocket(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("WebSockets", func() {
+		var server *httptest.Server
+
+		g.Before(func() {
+			mw := penname.New()
+			log = ledger.New(mw, ledger.DebugLevel)
+
+			f, err := Parse([]byte(websocketConfig))
+			Expect(err).To(BeNil())
+
+			server = httptest.NewServer(f)
+		})
+
+		g.After(func() {
+			server.Close()
+		})
+
+		dial := func(path string) *websocket.Conn {
+			u := "ws" + strings.TrimPrefix(server.URL, "http") + path
+
+			conn, res, err := websocket.DefaultDialer.Dial(u, nil)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(101))
+
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second)) //nolint:errcheck
+
+			return conn
+		}
+
+		read := func(conn *websocket.Conn) (int, string) {
+			typ, b, err := conn.ReadMessage()
+			Expect(err).To(BeNil())
+
+			return typ, string(b)
+		}
+
+		g.It("should send frames on connect", func() {
+			conn := dial("/v1/ws")
+			defer conn.Close()
+
+			typ, msg := read(conn)
+			Expect(typ).To(Equal(websocket.TextMessage))
+			Expect(msg).To(Equal(`{"type": "welcome"}`))
+
+			typ, msg = read(conn)
+			Expect(typ).To(Equal(websocket.BinaryMessage))
+			Expect(msg).To(ContainSubstring(`"foo": "here lies a foo"`))
+		})
+
+		g.It("should reply to messages matching a pattern", func() {
+			conn := dial("/v1/ws")
+			defer conn.Close()
+
+			read(conn)
+			read(conn)
+
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "subscribe", "channels": ["trades"]}`))).To(BeNil())
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "subscribe", "channels": ["prices"], "id": 7}`))).To(BeNil())
+
+			_, msg := read(conn)
+			Expect(msg).To(Equal(`{"type": "subscribed"}`))
+
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte("echo this"))).To(BeNil())
+
+			_, msg = read(conn)
+			Expect(msg).To(Equal("echoed"))
+		})
+
+		g.It("should only match scalars of the same type", func() {
+			Expect(containsJSON(1.0, 1)).To(BeTrue())
+			Expect(containsJSON(1.5, 1.5)).To(BeTrue())
+			Expect(containsJSON("1", "1")).To(BeTrue())
+			Expect(containsJSON(true, true)).To(BeTrue())
+
+			Expect(containsJSON("1", 1)).To(BeFalse())
+			Expect(containsJSON(1.0, "1")).To(BeFalse())
+			Expect(containsJSON("true", true)).To(BeFalse())
+			Expect(containsJSON(map[string]interface{}{"id": "7"}, map[string]interface{}{"id": 7})).To(BeFalse())
+			Expect(containsJSON([]interface{}{1.0}, []interface{}{1})).To(BeTrue())
+		})
+
+		g.It("should close with the code of a matching reply", func() {
+			conn := dial("/v1/ws")
+			defer conn.Close()
+
+			read(conn)
+			read(conn)
+
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte("bye"))).To(BeNil())
+
+			_, _, err := conn.ReadMessage()
+			Expect(websocket.IsCloseError(err, 4001)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("asked to leave"))
+		})
+
+		g.It("should ping periodically and close after a while", func() {
+			conn := dial("/v1/pinger")
+			defer conn.Close()
+
+			pings := 0
+			conn.SetPingHandler(func(string) error {
+				pings++
+				return nil
+			})
+
+			_, _, err := conn.ReadMessage()
+			Expect(websocket.IsCloseError(err, websocket.CloseGoingAway)).To(BeTrue())
+			Expect(pings).To(BeNumerically(">=", 2))
+		})
+
+		g.It("should refuse invalid scripts", func() {
+			_, err := Parse([]byte(`
+routes:
+  - endpoint: "/v1/missing"
+    type: "websocket"
+  - endpoint: "/v1/bad"
+    type: "websocket"
+    websocket:
+      ping: "often"
+      replies:
+        - text: "("
+          close:
+            code: 1006
+`))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("routes[0]: websocket is required for websocket routes"))
+			Expect(err.Error()).To(ContainSubstring(`routes[1].websocket.ping: invalid duration "often"`))
+			Expect(err.Error()).To(ContainSubstring("routes[1].websocket.replies[0].text: invalid text pattern"))
+			Expect(err.Error()).To(ContainSubstring("routes[1].websocket.replies[0].close.code: invalid close code 1006"))
+		})
+	})
+}
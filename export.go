@@ -0,0 +1,107 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/gomicro/duty/client"
+	"github.com/gomicro/duty/config"
+	"github.com/gomicro/duty/generate"
+	"gopkg.in/yaml.v3"
+)
+
+// export snapshots the current state of a running duty server, writing it as a
+// config file and payloads
+func export(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("export", "export [flags]", stderr)
+	addr := fs.String("url", "http://localhost:4567", "base url of the running duty server")
+	endpoint := fs.String("endpoint", "", "export endpoint of the duty server (default /duty/export)")
+	out := fs.String("out", defaultConfigFile, "config file to write, with payloads written relative to it")
+
+	code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	c := client.New(*addr)
+	if *endpoint != "" {
+		c.Endpoints.Export = *endpoint
+	}
+
+	b, err := c.Export(context.Background())
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	n, err := extractSnapshot(b, *out)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Exported config with %v payloads to %v\n", n, *out)
+
+	return 0
+}
+
+// extractSnapshot writes the config of a snapshot archive to the given path,
+// and its payloads relative to the directory of the config, returning the
+// number of payloads written
+func extractSnapshot(b []byte, configFile string) (int, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return 0, fmt.Errorf("Failed to read snapshot: %v", err.Error())
+	}
+
+	var conf []byte
+	payloads := generate.Payloads{}
+
+	for _, zf := range zr.File {
+		name := path.Clean(zf.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return 0, fmt.Errorf("snapshot holds a file outside of its directory: %v", zf.Name)
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return 0, fmt.Errorf("Failed to read snapshot: %v", err.Error())
+		}
+
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return 0, fmt.Errorf("Failed to read snapshot: %v", err.Error())
+		}
+
+		if name == config.SnapshotConfigFile {
+			conf = content
+			continue
+		}
+
+		payloads[name] = content
+	}
+
+	if conf == nil {
+		return 0, fmt.Errorf("snapshot holds no %v", config.SnapshotConfigFile)
+	}
+
+	var f config.File
+	err = yaml.Unmarshal(conf, &f)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to read snapshot: %v", err.Error())
+	}
+
+	err = generate.Write(&f, payloads, configFile)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(payloads), nil
+}
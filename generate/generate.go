@@ -0,0 +1,184 @@
+// Package generate builds duty configs from captured http exchanges, and
+// writes configs out as duty.yaml files alongside their payload files.
+package generate
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gomicro/duty/config"
+)
+
+var (
+	unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+)
+
+// Exchange represents a request made and the response returned for it
+type Exchange struct {
+	Method string
+	Host   string
+	Path   string
+	Code   int
+	Header http.Header
+	Body   []byte
+}
+
+// Payloads holds the contents of payload files keyed by the path the config
+// refers to them by
+type Payloads map[string][]byte
+
+// add adds the body at the given path, numbering the path when it is already
+// taken by another body, and returns the path it was added at
+func (p Payloads) add(name string, body []byte) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	unique := name
+	for i := 2; ; i++ {
+		if _, taken := p[unique]; !taken {
+			break
+		}
+
+		unique = fmt.Sprintf("%v_%v%v", base, i, ext)
+	}
+
+	p[unique] = body
+
+	return unique
+}
+
+// FromExchanges returns a config replaying the given exchanges, along with the
+// payload files holding their bodies within the payload directory. Repeated
+// requests to the same path become ordinal routes in the order they were
+// captured, and requests with different methods to the same path become verb
+// routes returning the first response captured for each method.
+func FromExchanges(exchanges []Exchange, payloadDir string) (*config.File, Payloads) {
+	var keys []string
+	grouped := make(map[string][]Exchange)
+
+	for _, e := range exchanges {
+		key := e.Host + e.Path
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		grouped[key] = append(grouped[key], e)
+	}
+
+	f := &config.File{}
+	payloads := Payloads{}
+
+	for _, key := range keys {
+		group := grouped[key]
+
+		r := config.Route{
+			Endpoint: group[0].Path,
+			Host:     group[0].Host,
+		}
+
+		var methods []string
+		byMethod := make(map[string]Exchange)
+		for _, e := range group {
+			m := strings.ToUpper(e.Method)
+			if _, ok := byMethod[m]; !ok {
+				methods = append(methods, m)
+				byMethod[m] = e
+			}
+		}
+
+		switch {
+		case len(methods) > 1:
+			r.Type = config.VerbRouteType
+			for _, m := range methods {
+				resp := response(byMethod[m], payloadDir, 0, payloads)
+				resp.Verb = m
+				r.Responses = append(r.Responses, resp)
+			}
+
+		case len(group) > 1:
+			r.Type = config.OrdinalRouteType
+			for i, e := range group {
+				r.Responses = append(r.Responses, response(e, payloadDir, i+1, payloads))
+			}
+
+		default:
+			r.Response = response(group[0], payloadDir, 0, payloads)
+		}
+
+		f.Routes = append(f.Routes, r)
+	}
+
+	return f, payloads
+}
+
+// response returns the response for the exchange, adding its body to the
+// payloads when it has one
+func response(e Exchange, payloadDir string, n int, payloads Payloads) config.Response {
+	resp := config.Response{
+		Code: e.Code,
+	}
+
+	ct := e.Header.Get("Content-Type")
+	if ct != "" {
+		resp.Headers = map[string]string{"Content-Type": ct}
+	}
+
+	if len(e.Body) == 0 {
+		return resp
+	}
+
+	resp.Payload = payloads.add(PayloadPath(payloadDir, e.Host, e.Path, e.Method, n, ct), e.Body)
+
+	return resp
+}
+
+// PayloadPath returns a path within the payload directory for the body of a
+// response, named after the request it was returned for and given an extension
+// from its content type
+func PayloadPath(payloadDir, host, urlPath, method string, n int, contentType string) string {
+	parts := []string{}
+	for _, p := range []string{host, urlPath, strings.ToLower(method)} {
+		p = strings.Trim(unsafeChars.ReplaceAllString(p, "_"), "_")
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	if len(parts) == 0 {
+		parts = append(parts, "root")
+	}
+
+	if n > 0 {
+		parts = append(parts, fmt.Sprintf("%v", n))
+	}
+
+	return path.Join(payloadDir, strings.Join(parts, "_")+extension(contentType))
+}
+
+func extension(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ".txt"
+	}
+
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		return ".json"
+
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		return ".xml"
+
+	case mt == "text/html":
+		return ".html"
+
+	case strings.HasPrefix(mt, "text/"):
+		return ".txt"
+
+	default:
+		return ".bin"
+	}
+}
@@ -0,0 +1,192 @@
+package generate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/duty/config"
+	. "github.com/onsi/gomega"
+)
+
+func TestGenerate(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	json := http.Header{"Content-Type": []string{"application/json"}}
+
+	g.Describe("From Exchanges", func() {
+		g.It("should build routes from exchanges", func() {
+			f, p := FromExchanges([]Exchange{
+				{Method: "GET", Path: "/v1/foo", Code: 200, Header: json, Body: []byte(`{"foo":1}`)},
+				{Method: "GET", Path: "/v1/ordinal", Code: 200, Header: json, Body: []byte(`{"n":1}`)},
+				{Method: "GET", Path: "/v1/verb", Code: 200, Header: json, Body: []byte(`{"got":1}`)},
+				{Method: "GET", Path: "/v1/ordinal", Code: 503},
+				{Method: "POST", Path: "/v1/verb", Code: 201},
+				{Method: "GET", Path: "/v1/verb", Code: 500},
+			}, "responses")
+
+			Expect(len(f.Routes)).To(Equal(3))
+
+			Expect(f.Routes[0].Endpoint).To(Equal("/v1/foo"))
+			Expect(f.Routes[0].Type).To(Equal(""))
+			Expect(f.Routes[0].Response.Code).To(Equal(200))
+			Expect(f.Routes[0].Response.Payload).To(Equal("responses/v1_foo_get.json"))
+			Expect(f.Routes[0].Response.Headers["Content-Type"]).To(Equal("application/json"))
+			Expect(string(p["responses/v1_foo_get.json"])).To(Equal(`{"foo":1}`))
+
+			Expect(f.Routes[1].Type).To(Equal(config.OrdinalRouteType))
+			Expect(len(f.Routes[1].Responses)).To(Equal(2))
+			Expect(f.Routes[1].Responses[0].Payload).To(Equal("responses/v1_ordinal_get_1.json"))
+			Expect(f.Routes[1].Responses[1].Code).To(Equal(503))
+			Expect(f.Routes[1].Responses[1].Payload).To(Equal(""))
+
+			Expect(f.Routes[2].Type).To(Equal(config.VerbRouteType))
+			Expect(len(f.Routes[2].Responses)).To(Equal(2))
+			Expect(f.Routes[2].Responses[0].Verb).To(Equal("GET"))
+			Expect(f.Routes[2].Responses[0].Code).To(Equal(200))
+			Expect(f.Routes[2].Responses[1].Verb).To(Equal("POST"))
+
+			Expect(len(p)).To(Equal(3))
+		})
+
+		g.It("should number payloads of paths that would share a file", func() {
+			f, p := FromExchanges([]Exchange{
+				{Method: "GET", Path: "/v1/users", Code: 200, Header: json, Body: []byte(`{"n":1}`)},
+				{Method: "GET", Path: "/v1/users/", Code: 200, Header: json, Body: []byte(`{"n":2}`)},
+				{Method: "GET", Path: "/a-b", Code: 200, Header: json, Body: []byte(`{"n":3}`)},
+				{Method: "GET", Path: "/a_b", Code: 200, Header: json, Body: []byte(`{"n":4}`)},
+				{Method: "GET", Path: "/a.b", Code: 200, Header: json, Body: []byte(`{"n":5}`)},
+			}, "responses")
+
+			Expect(len(f.Routes)).To(Equal(5))
+			Expect(f.Routes[0].Response.Payload).To(Equal("responses/v1_users_get.json"))
+			Expect(f.Routes[1].Response.Payload).To(Equal("responses/v1_users_get_2.json"))
+			Expect(f.Routes[2].Response.Payload).To(Equal("responses/a_b_get.json"))
+			Expect(f.Routes[3].Response.Payload).To(Equal("responses/a_b_get_2.json"))
+			Expect(f.Routes[4].Response.Payload).To(Equal("responses/a_b_get_3.json"))
+
+			Expect(len(p)).To(Equal(5))
+			Expect(string(p["responses/a_b_get_3.json"])).To(Equal(`{"n":5}`))
+		})
+	})
+
+	g.Describe("Recorder", func() {
+		g.It("should record the paths requested rather than those of the upstream", func() {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, r.URL.Path)
+			}))
+			defer upstream.Close()
+
+			u, _ := url.Parse(upstream.URL + "/base")
+			rec := NewRecorder(u)
+			server := httptest.NewServer(rec)
+			defer server.Close()
+
+			res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/v1/things"))
+			Expect(err).To(BeNil())
+
+			b, err := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal("/base/v1/things"))
+
+			exchanges := rec.Exchanges()
+			Expect(exchanges).To(HaveLen(1))
+			Expect(exchanges[0].Path).To(Equal("/v1/things"))
+		})
+
+		g.It("should record bodies the upstream would compress uncompressed", func() {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.Contains(r.Header.Get("Accept-Encoding"), "br") {
+					w.Header().Set("Content-Encoding", "br")
+					w.Write([]byte{0x0b, 0x01, 0x80}) //nolint:errcheck
+					return
+				}
+
+				if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+					w.Header().Set("Content-Encoding", "gzip")
+					gz := gzip.NewWriter(w)
+					gz.Write([]byte(`{"zipped":true}`)) //nolint:errcheck
+					gz.Close()
+					return
+				}
+
+				w.Write([]byte(`{"zipped":true}`)) //nolint:errcheck
+			}))
+			defer upstream.Close()
+
+			u, _ := url.Parse(upstream.URL)
+			rec := NewRecorder(u)
+			server := httptest.NewServer(rec)
+			defer server.Close()
+
+			req, err := http.NewRequest("GET", fmt.Sprintf("%v%v", server.URL, "/v1/zipped"), nil)
+			Expect(err).To(BeNil())
+			req.Header.Set("Accept-Encoding", "gzip, br")
+
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).To(BeNil())
+			res.Body.Close()
+
+			exchanges := rec.Exchanges()
+			Expect(exchanges).To(HaveLen(1))
+			Expect(string(exchanges[0].Body)).To(Equal(`{"zipped":true}`))
+			Expect(exchanges[0].Header.Get("Content-Encoding")).To(BeEmpty())
+		})
+
+		g.It("should record exchanges with the upstream and write them as a config", func() {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusAccepted)
+				fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+			}))
+			defer upstream.Close()
+
+			u, _ := url.Parse(upstream.URL)
+			rec := NewRecorder(u)
+			server := httptest.NewServer(rec)
+			defer server.Close()
+
+			res, err := http.Get(fmt.Sprintf("%v%v", server.URL, "/v1/recorded"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusAccepted))
+			Expect(string(b)).To(Equal(`{"path":"/v1/recorded"}`))
+
+			dir, err := ioutil.TempDir("", "duty")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			f, p := FromExchanges(rec.Exchanges(), "responses")
+			err = Write(f, p, filepath.Join(dir, "duty.yaml"))
+			Expect(err).To(BeNil())
+
+			written, err := config.ParseFile(filepath.Join(dir, "duty.yaml"))
+			Expect(err).To(BeNil())
+			Expect(written.Routes[0].Endpoint).To(Equal("/v1/recorded"))
+			Expect(written.Routes[0].Response.Code).To(Equal(http.StatusAccepted))
+			Expect(written.Routes[0].Response.Payload).To(Equal(filepath.ToSlash(filepath.Join(dir, "responses", "v1_recorded_get.json"))))
+
+			served := httptest.NewRecorder()
+			written.ServeHTTP(served, httptest.NewRequest("GET", "/v1/recorded", nil))
+			Expect(served.Code).To(Equal(http.StatusAccepted))
+			Expect(served.Body.String()).To(Equal(`{"path":"/v1/recorded"}`))
+
+			payload, err := ioutil.ReadFile(filepath.Join(dir, "responses", "v1_recorded_get.json"))
+			Expect(err).To(BeNil())
+			Expect(string(payload)).To(Equal(`{"path":"/v1/recorded"}`))
+		})
+	})
+}
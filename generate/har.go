@@ -0,0 +1,152 @@
+package generate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gomicro/duty/config"
+)
+
+// har represents the parts of an HTTP Archive capture describing the
+// exchanges within it
+type har struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	StartedDateTime string `json:"startedDateTime"`
+	Request         struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		Status  int         `json:"status"`
+		Headers []harHeader `json:"headers"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// startedEntry is a HAR entry along with the time it was started, for entries
+// whose start could be parsed
+type startedEntry struct {
+	harEntry
+	started time.Time
+	dated   bool
+}
+
+// FromHAR returns a config replaying the exchanges of the given HAR capture,
+// along with the payload files holding their bodies within the payload
+// directory. Exchanges are replayed as by FromExchanges, in the order they were
+// started, followed by any whose start is missing or invalid in the order they
+// were written. Routes are only bound to the host they were captured from when
+// the capture spans several hosts, and entries that never received a response
+// are skipped.
+func FromHAR(b []byte, payloadDir string) (*config.File, Payloads, error) {
+	var h har
+	err := json.Unmarshal(b, &h)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to unmarshal HAR: %v", err.Error())
+	}
+
+	entries := make([]startedEntry, len(h.Log.Entries))
+	for i, e := range h.Log.Entries {
+		t, err := time.Parse(time.RFC3339Nano, e.StartedDateTime)
+		entries[i] = startedEntry{harEntry: e, started: t, dated: err == nil}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].dated != entries[j].dated {
+			return entries[i].dated
+		}
+
+		return entries[i].started.Before(entries[j].started)
+	})
+
+	var exchanges []Exchange
+	hosts := make(map[string]bool)
+
+	for i, se := range entries {
+		e := se.harEntry
+
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("entry %v: invalid url: %v", i, err.Error())
+		}
+
+		if (u.Scheme != "http" && u.Scheme != "https") || e.Response.Status == 0 {
+			continue
+		}
+
+		body, err := harBody(e)
+		if err != nil {
+			return nil, nil, fmt.Errorf("entry %v: %v", i, err.Error())
+		}
+
+		header := http.Header{}
+		for _, hh := range e.Response.Headers {
+			header.Add(hh.Name, hh.Value)
+		}
+
+		if header.Get("Content-Type") == "" && e.Response.Content.MimeType != "" && len(body) > 0 {
+			header.Set("Content-Type", e.Response.Content.MimeType)
+		}
+
+		p := u.Path
+		if p == "" {
+			p = "/"
+		}
+
+		hosts[u.Hostname()] = true
+
+		exchanges = append(exchanges, Exchange{
+			Method: e.Request.Method,
+			Host:   u.Hostname(),
+			Path:   p,
+			Code:   e.Response.Status,
+			Header: header,
+			Body:   body,
+		})
+	}
+
+	if len(hosts) == 1 {
+		for i := range exchanges {
+			exchanges[i].Host = ""
+		}
+	}
+
+	f, payloads := FromExchanges(exchanges, payloadDir)
+
+	return f, payloads, nil
+}
+
+// harBody returns the body of the response of the entry, decoding it when it
+// was captured as base64
+func harBody(e harEntry) ([]byte, error) {
+	c := e.Response.Content
+	if c.Encoding != "base64" {
+		return []byte(c.Text), nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(c.Text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 body: %v", err.Error())
+	}
+
+	return b, nil
+}
@@ -0,0 +1,103 @@
+package generate
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/duty/config"
+	. "github.com/onsi/gomega"
+)
+
+func TestHAR(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("From HAR", func() {
+		var f *config.File
+		var p Payloads
+
+		g.Before(func() {
+			b, err := ioutil.ReadFile("./session.har")
+			Expect(err).To(BeNil())
+
+			f, p, err = FromHAR(b, "responses")
+			Expect(err).To(BeNil())
+		})
+
+		g.It("should replay exchanges in the order they were started", func() {
+			Expect(len(f.Routes)).To(Equal(2))
+			Expect(f.Routes[0].Endpoint).To(Equal("/v1/cart"))
+			Expect(f.Routes[1].Endpoint).To(Equal("/v1/items"))
+		})
+
+		g.It("should not bind routes of a single host capture to the host", func() {
+			Expect(f.Routes[0].Host).To(Equal(""))
+			Expect(f.Routes[1].Host).To(Equal(""))
+		})
+
+		g.It("should build ordinal routes from repeated requests", func() {
+			r := f.Routes[0]
+			Expect(r.Type).To(Equal(config.OrdinalRouteType))
+			Expect(len(r.Responses)).To(Equal(2))
+			Expect(r.Responses[0].Payload).To(Equal("responses/v1_cart_get_1.json"))
+			Expect(string(p["responses/v1_cart_get_1.json"])).To(Equal(`{"items":[]}`))
+			Expect(string(p["responses/v1_cart_get_2.json"])).To(Equal(`{"items":[1]}`))
+		})
+
+		g.It("should build verb routes from requests with different methods", func() {
+			r := f.Routes[1]
+			Expect(r.Type).To(Equal(config.VerbRouteType))
+			Expect(len(r.Responses)).To(Equal(2))
+			Expect(r.Responses[0].Verb).To(Equal("POST"))
+			Expect(r.Responses[0].Code).To(Equal(201))
+			Expect(r.Responses[0].Headers["Content-Type"]).To(Equal("application/json"))
+			Expect(r.Responses[1].Verb).To(Equal("DELETE"))
+			Expect(r.Responses[1].Code).To(Equal(204))
+			Expect(r.Responses[1].Payload).To(Equal(""))
+		})
+
+		g.It("should bind routes to their host when the capture spans hosts", func() {
+			f, _, err := FromHAR([]byte(`{"log": {"entries": [
+				{"request": {"method": "GET", "url": "http://a.example.test/v1/foo"}, "response": {"status": 200}},
+				{"request": {"method": "GET", "url": "http://b.example.test/v1/foo"}, "response": {"status": 404}}
+			]}}`), "responses")
+			Expect(err).To(BeNil())
+
+			Expect(len(f.Routes)).To(Equal(2))
+			Expect(f.Routes[0].Host).To(Equal("a.example.test"))
+			Expect(f.Routes[1].Host).To(Equal("b.example.test"))
+		})
+
+		g.It("should route requests by their unescaped path", func() {
+			f, _, err := FromHAR([]byte(`{"log": {"entries": [
+				{"request": {"method": "GET", "url": "http://a.example.test/v1/caf%C3%A9/a%20b"}, "response": {"status": 200}}
+			]}}`), "responses")
+			Expect(err).To(BeNil())
+
+			Expect(len(f.Routes)).To(Equal(1))
+			Expect(f.Routes[0].Endpoint).To(Equal("/v1/café/a b"))
+		})
+
+		g.It("should replay entries without a valid start last", func() {
+			f, _, err := FromHAR([]byte(`{"log": {"entries": [
+				{"request": {"method": "GET", "url": "http://a.example.test/v1/a"}, "response": {"status": 200}},
+				{"startedDateTime": "2024-03-01T10:00:02.000Z", "request": {"method": "GET", "url": "http://a.example.test/v1/b"}, "response": {"status": 200}},
+				{"startedDateTime": "yesterday", "request": {"method": "GET", "url": "http://a.example.test/v1/c"}, "response": {"status": 200}},
+				{"startedDateTime": "2024-03-01T10:00:01.000Z", "request": {"method": "GET", "url": "http://a.example.test/v1/d"}, "response": {"status": 200}}
+			]}}`), "responses")
+			Expect(err).To(BeNil())
+
+			var endpoints []string
+			for _, r := range f.Routes {
+				endpoints = append(endpoints, r.Endpoint)
+			}
+			Expect(endpoints).To(Equal([]string{"/v1/d", "/v1/b", "/v1/a", "/v1/c"}))
+		})
+
+		g.It("should refuse documents that are not HAR", func() {
+			_, _, err := FromHAR([]byte(`not json`), "responses")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+}
@@ -0,0 +1,312 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gomicro/duty/config"
+	"github.com/gomicro/duty/openapi"
+)
+
+// FromOpenAPI returns a config serving every operation of the given OpenAPI 3
+// document, in YAML or JSON, along with the payload files holding the bodies of
+// its responses within the payload directory. Each path becomes a verb route,
+// with its templates kept as path parameters, returning a response for each
+// status code documented by its operations. Responses are given IDs of their
+// method and status code, such as `get-404`, to be selected through the set
+// endpoint, and bodies are taken from the examples of a response or generated
+// from its schema. Parameters sharing a path segment with other text match the
+// whole segment.
+func FromOpenAPI(b []byte, payloadDir string) (*config.File, Payloads, error) {
+	doc, err := openapi.Parse(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base := doc.BasePath()
+
+	f := &config.File{}
+	payloads := Payloads{}
+	names := make(map[string]bool)
+
+	for _, p := range doc.SortedPaths() {
+		endpoint := openAPIEndpoint(base + p)
+
+		r := config.Route{
+			Endpoint: endpoint,
+			Type:     config.VerbRouteType,
+			Name:     uniqueName(names, endpoint),
+		}
+
+		item := doc.Paths[p]
+		if item == nil {
+			continue
+		}
+
+		for _, method := range openapi.Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+
+			for _, code := range sortedCodes(op.Responses) {
+				resp, err := operationResponse(doc, op.Responses[code], endpoint, method, code, payloadDir, payloads)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%v %v: %v", method, p, err.Error())
+				}
+
+				r.Responses = append(r.Responses, resp)
+			}
+		}
+
+		if len(r.Responses) == 0 {
+			continue
+		}
+
+		f.Routes = append(f.Routes, r)
+	}
+
+	return f, payloads, nil
+}
+
+// operationResponse returns the duty response for a documented status code,
+// adding any body it has to the payloads
+func operationResponse(doc *openapi.Document, or *openapi.Response, endpoint, method, code, payloadDir string, payloads Payloads) (config.Response, error) {
+	resp := config.Response{
+		Code: statusCode(code),
+		Verb: method,
+		ID:   strings.ToLower(method + "-" + code),
+	}
+
+	or = doc.ResolveResponse(or)
+	if or == nil {
+		return resp, nil
+	}
+
+	for name, h := range or.Headers {
+		h = doc.ResolveHeader(h)
+		if h == nil || strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+
+		v := h.Example
+		if v == nil && h.Schema != nil {
+			v = doc.Sample(h.Schema)
+		}
+
+		if v == nil {
+			continue
+		}
+
+		if resp.Headers == nil {
+			resp.Headers = map[string]string{}
+		}
+
+		resp.Headers[name] = fmt.Sprintf("%v", v)
+	}
+
+	ct, mt := preferredContent(or.Content)
+	if mt == nil {
+		return resp, nil
+	}
+
+	if resp.Headers == nil {
+		resp.Headers = map[string]string{}
+	}
+	resp.Headers["Content-Type"] = ct
+
+	body, err := exampleBody(doc, ct, mt)
+	if err != nil {
+		return resp, err
+	}
+
+	if body == nil {
+		return resp, nil
+	}
+
+	resp.Payload = payloads.add(PayloadPath(payloadDir, "", endpoint, method+"_"+code, 0, ct), body)
+
+	return resp, nil
+}
+
+// exampleBody returns the body of a media type from its examples, or generated
+// from its schema when it is JSON
+func exampleBody(doc *openapi.Document, ct string, mt *openapi.MediaType) ([]byte, error) {
+	v := mt.Example
+
+	if v == nil && len(mt.Examples) > 0 {
+		names := make([]string, 0, len(mt.Examples))
+		for n := range mt.Examples {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		ex := doc.ResolveExample(mt.Examples[names[0]])
+		if ex != nil {
+			v = ex.Value
+		}
+	}
+
+	jsonBody := openapi.IsJSON(ct)
+
+	if v == nil && jsonBody && mt.Schema != nil {
+		v = doc.Sample(mt.Schema)
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	if s, ok := v.(string); ok && !jsonBody {
+		return []byte(s), nil
+	}
+
+	if !jsonBody {
+		return nil, nil
+	}
+
+	b, err := json.MarshalIndent(jsonValue(v), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal example: %v", err.Error())
+	}
+
+	return append(b, '\n'), nil
+}
+
+// preferredContent returns the JSON media type of the content when it has one,
+// and otherwise the first media type by name
+func preferredContent(content map[string]*openapi.MediaType) (string, *openapi.MediaType) {
+	types := make([]string, 0, len(content))
+	for ct := range content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+
+	for _, ct := range types {
+		if openapi.IsJSON(ct) && content[ct] != nil {
+			return ct, content[ct]
+		}
+	}
+
+	for _, ct := range types {
+		if content[ct] != nil {
+			return ct, content[ct]
+		}
+	}
+
+	return "", nil
+}
+
+// sortedCodes orders the documented status codes numerically, placing ranges
+// such as 4XX after the codes within them and the default response last
+func sortedCodes(responses map[string]*openapi.Response) []string {
+	codes := make([]string, 0, len(responses))
+	for c := range responses {
+		codes = append(codes, c)
+	}
+
+	sort.Slice(codes, func(i, j int) bool {
+		return codeOrder(codes[i]) < codeOrder(codes[j])
+	})
+
+	return codes
+}
+
+func codeOrder(code string) int {
+	if strings.EqualFold(code, "default") {
+		return 1000
+	}
+
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return statusCode(code) + 99
+	}
+
+	return n
+}
+
+// statusCode returns the status code to respond with for a documented code,
+// responding to ranges with their first code and the default with a 500
+func statusCode(code string) int {
+	n, err := strconv.Atoi(code)
+	if err == nil {
+		return n
+	}
+
+	if len(code) == 3 && strings.EqualFold(code[1:], "XX") && code[0] >= '1' && code[0] <= '5' {
+		return int(code[0]-'0') * 100
+	}
+
+	return 500
+}
+
+// openAPIEndpoint returns the endpoint of an OpenAPI path, widening parameters
+// that share a segment with other text to the whole segment
+func openAPIEndpoint(p string) string {
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		start := strings.Index(seg, "{")
+		end := strings.Index(seg, "}")
+
+		if start < 0 || end < start || (start == 0 && end == len(seg)-1) {
+			continue
+		}
+
+		segs[i] = seg[start : end+1]
+	}
+
+	return strings.Join(segs, "/")
+}
+
+// uniqueName returns a route name derived from the endpoint that has not been
+// used yet
+func uniqueName(names map[string]bool, endpoint string) string {
+	base := strings.Trim(unsafeChars.ReplaceAllString(endpoint, "_"), "_")
+	if base == "" {
+		base = "root"
+	}
+
+	name := base
+	for i := 2; names[name]; i++ {
+		name = fmt.Sprintf("%v_%v", base, i)
+	}
+
+	names[name] = true
+
+	return name
+}
+
+// jsonValue converts values decoded from YAML into values that may be encoded
+// as JSON
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[k] = jsonValue(val)
+		}
+
+		return m
+
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprintf("%v", k)] = jsonValue(val)
+		}
+
+		return m
+
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, val := range t {
+			s[i] = jsonValue(val)
+		}
+
+		return s
+	}
+
+	return v
+}
@@ -0,0 +1,74 @@
+package generate
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/duty/config"
+	. "github.com/onsi/gomega"
+)
+
+func TestOpenAPI(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("From OpenAPI", func() {
+		var f *config.File
+		var p Payloads
+
+		g.Before(func() {
+			b, err := ioutil.ReadFile("./petstore.yaml")
+			Expect(err).To(BeNil())
+
+			f, p, err = FromOpenAPI(b, "responses")
+			Expect(err).To(BeNil())
+		})
+
+		g.It("should build a verb route for each path", func() {
+			Expect(len(f.Routes)).To(Equal(3))
+
+			Expect(f.Routes[0].Endpoint).To(Equal("/v1/pets"))
+			Expect(f.Routes[0].Type).To(Equal(config.VerbRouteType))
+			Expect(f.Routes[0].Name).To(Equal("v1_pets"))
+
+			Expect(f.Routes[1].Endpoint).To(Equal("/v1/pets/{petId}"))
+			Expect(f.Routes[2].Endpoint).To(Equal("/v1/pets/{petId}/{ext}"))
+		})
+
+		g.It("should build a response for each status code", func() {
+			resps := f.Routes[0].Responses
+			Expect(len(resps)).To(Equal(3))
+
+			Expect(resps[0].ID).To(Equal("get-200"))
+			Expect(resps[0].Verb).To(Equal(http.MethodGet))
+			Expect(resps[0].Headers["X-Next"]).To(Equal("/v1/pets?page=2"))
+			Expect(resps[1].ID).To(Equal("get-default"))
+			Expect(resps[1].Code).To(Equal(500))
+			Expect(resps[2].ID).To(Equal("post-201"))
+			Expect(resps[2].Payload).To(Equal(""))
+
+			resps = f.Routes[1].Responses
+			Expect(resps[0].ID).To(Equal("get-200"))
+			Expect(resps[1].ID).To(Equal("get-404"))
+		})
+
+		g.It("should take bodies from examples or generate them from schemas", func() {
+			Expect(string(p[f.Routes[0].Responses[0].Payload])).To(MatchJSON(`[{"id": 0, "name": "string", "tag": "dog"}]`))
+			Expect(string(p[f.Routes[0].Responses[1].Payload])).To(MatchJSON(`{"code": 0, "message": "something went wrong"}`))
+			Expect(string(p[f.Routes[1].Responses[0].Payload])).To(MatchJSON(`{"id": 7, "name": "Rex"}`))
+			Expect(f.Routes[1].Responses[0].Payload).To(Equal("responses/v1_pets_petId_get_200.json"))
+			Expect(string(p[f.Routes[2].Responses[0].Payload])).To(Equal("not really a photo"))
+		})
+
+		g.It("should build a valid config", func() {
+			Expect(f.Validate()).To(BeEmpty())
+		})
+
+		g.It("should refuse documents other than OpenAPI 3", func() {
+			_, _, err := FromOpenAPI([]byte(`swagger: "2.0"`), "responses")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+}
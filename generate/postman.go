@@ -0,0 +1,353 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gomicro/duty/config"
+)
+
+const (
+	postmanSchema = "v2.1.0"
+)
+
+var (
+	postmanVariable = regexp.MustCompile(`^\{\{\s*([^}]+?)\s*\}\}$`)
+
+	// skippedHeaders are the headers of saved responses that describe how they
+	// were transferred rather than what they hold
+	skippedHeaders = map[string]bool{
+		"Content-Length":    true,
+		"Content-Encoding":  true,
+		"Transfer-Encoding": true,
+		"Connection":        true,
+		"Keep-Alive":        true,
+		"Date":              true,
+	}
+)
+
+// postmanCollection represents the parts of a Postman v2.1 collection
+// describing its requests and their saved responses
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item []postmanItem `json:"item"`
+}
+
+// postmanItem represents either a folder of items or a request
+type postmanItem struct {
+	Name     string            `json:"name"`
+	Item     []postmanItem     `json:"item"`
+	Request  *postmanRequest   `json:"request"`
+	Response []postmanResponse `json:"response"`
+}
+
+type postmanRequest struct {
+	Method string     `json:"method"`
+	URL    postmanURL `json:"url"`
+}
+
+// postmanURL represents the url of a request, given either as a raw string or
+// as an object of its parts
+type postmanURL struct {
+	Raw  string   `json:"raw"`
+	Path []string `json:"-"`
+}
+
+type postmanResponse struct {
+	Name            string          `json:"name"`
+	Code            int             `json:"code"`
+	Header          []postmanHeader `json:"header"`
+	Body            string          `json:"body"`
+	PreviewLanguage string          `json:"_postman_previewlanguage"`
+}
+
+type postmanHeader struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+// postmanExample is a saved response along with the request it was saved for
+type postmanExample struct {
+	name     string
+	method   string
+	response postmanResponse
+}
+
+// UnmarshalJSON reads urls given as strings or as objects, taking the path of
+// object urls from their path segments
+func (u *postmanURL) UnmarshalJSON(b []byte) error {
+	var raw string
+	if json.Unmarshal(b, &raw) == nil {
+		u.Raw = raw
+		return nil
+	}
+
+	var obj struct {
+		Raw  string          `json:"raw"`
+		Path json.RawMessage `json:"path"`
+	}
+
+	err := json.Unmarshal(b, &obj)
+	if err != nil {
+		return err
+	}
+
+	u.Raw = obj.Raw
+
+	if len(obj.Path) == 0 {
+		return nil
+	}
+
+	var path string
+	if json.Unmarshal(obj.Path, &path) == nil {
+		u.Path = strings.Split(strings.Trim(path, "/"), "/")
+		return nil
+	}
+
+	var segs []interface{}
+	err = json.Unmarshal(obj.Path, &segs)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range segs {
+		switch v := s.(type) {
+		case string:
+			u.Path = append(u.Path, v)
+
+		case map[string]interface{}:
+			u.Path = append(u.Path, fmt.Sprintf("%v", v["value"]))
+		}
+	}
+
+	return nil
+}
+
+// FromPostman returns a config serving the saved responses of the requests
+// within the given Postman v2.1 collection, along with the payload files
+// holding their bodies within the payload directory. The saved responses of a
+// request become the responses of a variable route, given IDs from their names
+// to be selected through the set endpoint, and requests with different methods
+// to the same path become a verb route. Routes are named after the folders and
+// names of their requests, and path variables such as `:id` or `{{id}}` become
+// path parameters. Requests without saved responses are skipped.
+func FromPostman(b []byte, payloadDir string) (*config.File, Payloads, error) {
+	var c postmanCollection
+	err := json.Unmarshal(b, &c)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to unmarshal Postman collection: %v", err.Error())
+	}
+
+	if c.Info.Schema != "" && !strings.Contains(c.Info.Schema, postmanSchema) {
+		return nil, nil, fmt.Errorf("unsupported Postman collection schema %v", c.Info.Schema)
+	}
+
+	var endpoints []string
+	grouped := make(map[string][]postmanExample)
+	routeNames := make(map[string]string)
+
+	var walk func(items []postmanItem, folder string)
+	walk = func(items []postmanItem, folder string) {
+		for _, item := range items {
+			name := strings.Trim(folder+"/"+item.Name, "/")
+
+			if item.Request == nil {
+				walk(item.Item, name)
+				continue
+			}
+
+			if len(item.Response) == 0 {
+				continue
+			}
+
+			endpoint := postmanEndpoint(item.Request.URL)
+			if _, ok := grouped[endpoint]; !ok {
+				endpoints = append(endpoints, endpoint)
+				routeNames[endpoint] = name
+			}
+
+			method := strings.ToUpper(item.Request.Method)
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			for _, r := range item.Response {
+				grouped[endpoint] = append(grouped[endpoint], postmanExample{
+					name:     r.Name,
+					method:   method,
+					response: r,
+				})
+			}
+		}
+	}
+	walk(c.Item, "")
+
+	f := &config.File{}
+	payloads := Payloads{}
+	names := make(map[string]bool)
+
+	for _, endpoint := range endpoints {
+		examples := grouped[endpoint]
+
+		r := config.Route{
+			Endpoint: endpoint,
+			Type:     config.VariableRouteType,
+			Name:     uniqueName(names, routeNames[endpoint]),
+		}
+
+		for _, ex := range examples {
+			if ex.method != examples[0].method {
+				r.Type = config.VerbRouteType
+				break
+			}
+		}
+
+		ids := make(map[string]bool)
+
+		for _, ex := range examples {
+			resp := postmanResponseOf(ex, endpoint, uniqueID(ids, ex.name), payloadDir, payloads)
+			if r.Type == config.VerbRouteType {
+				resp.Verb = ex.method
+			}
+
+			r.Responses = append(r.Responses, resp)
+		}
+
+		f.Routes = append(f.Routes, r)
+	}
+
+	return f, payloads, nil
+}
+
+// postmanResponseOf returns the duty response for a saved response, adding
+// its body to the payloads when it has one
+func postmanResponseOf(ex postmanExample, endpoint, id, payloadDir string, payloads Payloads) config.Response {
+	pr := ex.response
+
+	resp := config.Response{
+		Code: pr.Code,
+		ID:   id,
+	}
+
+	if resp.Code == 0 {
+		resp.Code = http.StatusOK
+	}
+
+	for _, h := range pr.Header {
+		key := http.CanonicalHeaderKey(h.Key)
+		if h.Disabled || key == "" || skippedHeaders[key] {
+			continue
+		}
+
+		if resp.Headers == nil {
+			resp.Headers = map[string]string{}
+		}
+
+		resp.Headers[key] = h.Value
+	}
+
+	ct := resp.Headers["Content-Type"]
+	if ct == "" && pr.Body != "" {
+		ct = previewContentType(pr.PreviewLanguage)
+		if ct != "" {
+			if resp.Headers == nil {
+				resp.Headers = map[string]string{}
+			}
+
+			resp.Headers["Content-Type"] = ct
+		}
+	}
+
+	if pr.Body == "" {
+		return resp
+	}
+
+	resp.Payload = payloads.add(PayloadPath(payloadDir, "", endpoint, ex.method+"_"+id, 0, ct), []byte(pr.Body))
+
+	return resp
+}
+
+// postmanEndpoint returns the endpoint of a request url, dropping its host,
+// query and fragment, and turning its path variables into path parameters
+func postmanEndpoint(u postmanURL) string {
+	segs := u.Path
+
+	if segs == nil {
+		raw := u.Raw
+		if i := strings.IndexAny(raw, "?#"); i >= 0 {
+			raw = raw[:i]
+		}
+
+		if i := strings.Index(raw, "://"); i >= 0 {
+			raw = raw[i+3:]
+		}
+
+		parts := strings.Split(raw, "/")
+		if len(parts) > 0 && !strings.HasPrefix(raw, "/") {
+			parts = parts[1:]
+		}
+
+		for _, p := range parts {
+			if p != "" {
+				segs = append(segs, p)
+			}
+		}
+	}
+
+	out := make([]string, 0, len(segs))
+	for _, s := range segs {
+		switch {
+		case strings.HasPrefix(s, ":") && len(s) > 1:
+			s = "{" + s[1:] + "}"
+
+		case postmanVariable.MatchString(s):
+			s = "{" + postmanVariable.FindStringSubmatch(s)[1] + "}"
+		}
+
+		out = append(out, s)
+	}
+
+	return "/" + strings.Join(out, "/")
+}
+
+// previewContentType returns the content type of a body Postman previews in
+// the given language
+func previewContentType(lang string) string {
+	switch strings.ToLower(lang) {
+	case "json":
+		return "application/json"
+	case "xml":
+		return "application/xml"
+	case "html":
+		return "text/html"
+	case "text":
+		return "text/plain"
+	}
+
+	return ""
+}
+
+// uniqueID returns a response ID derived from the name of a saved response
+// that has not been used yet within its route
+func uniqueID(ids map[string]bool, name string) string {
+	base := strings.Trim(unsafeChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if base == "" {
+		base = "response"
+	}
+
+	id := base
+	for i := 2; ids[id]; i++ {
+		id = fmt.Sprintf("%v-%v", base, i)
+	}
+
+	ids[id] = true
+
+	return id
+}
@@ -0,0 +1,68 @@
+package generate
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/duty/config"
+	. "github.com/onsi/gomega"
+)
+
+func TestPostman(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("From Postman", func() {
+		var f *config.File
+		var p Payloads
+
+		g.Before(func() {
+			b, err := ioutil.ReadFile("./partners.postman_collection.json")
+			Expect(err).To(BeNil())
+
+			f, p, err = FromPostman(b, "responses")
+			Expect(err).To(BeNil())
+		})
+
+		g.It("should build a route for each path with saved responses", func() {
+			Expect(len(f.Routes)).To(Equal(2))
+			Expect(f.Routes[0].Endpoint).To(Equal("/v1/accounts/{accountId}"))
+			Expect(f.Routes[0].Name).To(Equal("Accounts_Get_Account"))
+			Expect(f.Routes[1].Endpoint).To(Equal("/v1/orders"))
+		})
+
+		g.It("should build variable routes with IDs from example names", func() {
+			r := f.Routes[1]
+			Expect(r.Type).To(Equal(config.VariableRouteType))
+			Expect(len(r.Responses)).To(Equal(2))
+			Expect(r.Responses[0].ID).To(Equal("empty"))
+			Expect(r.Responses[1].ID).To(Equal("empty-2"))
+			Expect(r.Responses[1].Payload).To(Equal("responses/v1_orders_get_empty_2.json"))
+			Expect(string(p["responses/v1_orders_get_empty_2.json"])).To(Equal(`[{"id":1}]`))
+		})
+
+		g.It("should build verb routes from requests with different methods", func() {
+			r := f.Routes[0]
+			Expect(r.Type).To(Equal(config.VerbRouteType))
+			Expect(len(r.Responses)).To(Equal(3))
+
+			Expect(r.Responses[0].Verb).To(Equal("GET"))
+			Expect(r.Responses[0].ID).To(Equal("found"))
+			Expect(r.Responses[0].Headers).To(Equal(map[string]string{"Content-Type": "application/json"}))
+
+			Expect(r.Responses[1].ID).To(Equal("not-found"))
+			Expect(r.Responses[1].Code).To(Equal(404))
+			Expect(r.Responses[1].Headers["Content-Type"]).To(Equal("application/json"))
+
+			Expect(r.Responses[2].Verb).To(Equal("DELETE"))
+			Expect(r.Responses[2].Code).To(Equal(204))
+			Expect(r.Responses[2].Payload).To(Equal(""))
+		})
+
+		g.It("should refuse collections of other schemas", func() {
+			_, _, err := FromPostman([]byte(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}}`), "responses")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+}
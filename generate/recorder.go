@@ -0,0 +1,79 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+)
+
+// Recorder is an http.Handler proxying requests to an upstream service and
+// recording each exchange made with it
+type Recorder struct {
+	proxy     *httputil.ReverseProxy
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// pathKey holds the path requested of the recorder, before the proxy joins the
+// path of the upstream url to it
+type pathKey struct{}
+
+// NewRecorder returns a recorder proxying requests to the given upstream url.
+// The encodings accepted by clients are not forwarded, so bodies are recorded
+// as they would be served rather than compressed.
+func NewRecorder(target *url.URL) *Recorder {
+	rec := &Recorder{}
+
+	rec.proxy = httputil.NewSingleHostReverseProxy(target)
+	director := rec.proxy.Director
+	rec.proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+		req.Header.Del("Accept-Encoding")
+	}
+	rec.proxy.ModifyResponse = rec.capture
+
+	return rec
+}
+
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := context.WithValue(req.Context(), pathKey{}, req.URL.Path)
+	rec.proxy.ServeHTTP(w, req.WithContext(ctx))
+}
+
+func (rec *Recorder) capture(res *http.Response) error {
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	path, _ := res.Request.Context().Value(pathKey{}).(string)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.exchanges = append(rec.exchanges, Exchange{
+		Method: res.Request.Method,
+		Path:   path,
+		Code:   res.StatusCode,
+		Header: res.Header.Clone(),
+		Body:   b,
+	})
+
+	return nil
+}
+
+// Exchanges returns the exchanges recorded so far, in the order they completed
+func (rec *Recorder) Exchanges() []Exchange {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return append([]Exchange{}, rec.exchanges...)
+}
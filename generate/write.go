@@ -0,0 +1,58 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gomicro/duty/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Write writes the config to the given path as YAML, and each of the payloads
+// to its path relative to the directory of the config file. The config is
+// rebased onto that directory, as payloads are read relative to the working
+// directory.
+func Write(f *config.File, payloads Payloads, configFile string) error {
+	dir := filepath.Dir(configFile)
+	f.Rebase(dir)
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	err := enc.Encode(f)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal config: %v", err.Error())
+	}
+
+	err = enc.Close()
+	if err != nil {
+		return fmt.Errorf("Failed to marshal config: %v", err.Error())
+	}
+
+	for p, b := range payloads {
+		out := filepath.Join(dir, filepath.FromSlash(p))
+
+		err = os.MkdirAll(filepath.Dir(out), 0755)
+		if err != nil {
+			return fmt.Errorf("Failed to create payload directory: %v", err.Error())
+		}
+
+		err = ioutil.WriteFile(out, b, 0644)
+		if err != nil {
+			return fmt.Errorf("Failed to write payload: %v", err.Error())
+		}
+	}
+
+	err = ioutil.WriteFile(configFile, buf.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write config file: %v", err.Error())
+	}
+
+	return nil
+}
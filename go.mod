@@ -1,11 +1,23 @@
 module github.com/gomicro/duty
 
-go 1.16
+go 1.17
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/franela/goblin v0.0.0-20210519012713-85d372ac71e2
 	github.com/gomicro/ledger v0.1.1
 	github.com/gomicro/penname v0.1.1
+	github.com/gorilla/websocket v1.5.0
+	github.com/jhump/protoreflect v1.9.0
 	github.com/onsi/gomega v1.16.0
-	gopkg.in/yaml.v2 v2.4.0
+	golang.org/x/net v0.11.0
+	google.golang.org/protobuf v1.28.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/golang/protobuf v1.5.2 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/gomicro/duty/config"
+	"github.com/gomicro/duty/generate"
+)
+
+// importer builds a config and its payloads from a document of another format
+type importer func(b []byte, payloadDir string) (*config.File, generate.Payloads, error)
+
+var (
+	importers = map[string]importer{
+		"har":     generate.FromHAR,
+		"openapi": generate.FromOpenAPI,
+		"postman": generate.FromPostman,
+	}
+)
+
+// importDoc converts a document of another format, such as an OpenAPI spec,
+// into a config file and payloads
+func importDoc(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("import", "import --from <format> [flags] <file>", stderr)
+	from := fs.String("from", "", "format of the document to import, one of "+strings.Join(importFormats(), ", ")+" (required)")
+	out := fs.String("out", defaultConfigFile, "config file to write")
+	payloads := fs.String("payloads", "responses", "directory, relative to the config file, to write payloads into")
+
+	code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	imp, found := importers[strings.ToLower(*from)]
+	if !found || fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	b, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to read document: %v\n", err.Error())
+		return 1
+	}
+
+	f, p, err := imp(b, *payloads)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	err = generate.Write(f, p, *out)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Imported %v routes to %v\n", len(f.Routes), *out)
+
+	return 0
+}
+
+func importFormats() []string {
+	names := make([]string, 0, len(importers))
+	for n := range importers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	return names
+}
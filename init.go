@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	exampleConfig = `---
+routes:
+  - endpoint: "/v1/foo"
+    response:
+      code: 200
+      headers:
+        Content-Type: "application/json"
+      payload: "responses/foo.json"
+
+  - endpoint: "/v1/ordinal"
+    type: "ordinal"
+    responses:
+      - code: 200
+        payload: "responses/foo.json"
+      - code: 401
+        payload: "responses/unauthorized.json"
+
+  - endpoint: "/v1/variable"
+    type: "variable"
+    name: "var"
+    responses:
+      - code: 200
+        payload: "responses/foo.json"
+        id: "200"
+      - code: 404
+        payload: "responses/notfound.json"
+        id: "404"
+
+  - endpoint: "/v1/verb"
+    type: "verb"
+    responses:
+      - verb: GET
+        code: 200
+        payload: "responses/foo.json"
+      - verb: POST
+        code: 201
+        payload: "responses/created.json"
+`
+
+	exampleFoo = `{
+	"foo": "here lies a foo",
+	"bar": "git to the bar",
+	"baz": "let's get the baz back together"
+}
+`
+
+	exampleCreated = `{
+	"foo": "we've created a foo"
+}
+`
+
+	exampleUnauthorized = `{
+	"message": "unauthorized"
+}
+`
+
+	exampleNotFound = `{
+	"message": "not found"
+}
+`
+)
+
+// scaffold writes an example config file and the payloads it refers to into a
+// directory, refusing to overwrite existing files unless forced
+func scaffold(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("init", "init [flags]", stderr)
+	dir := fs.String("dir", ".", "directory to write the example config into")
+	force := fs.Bool("force", false, "overwrite existing files")
+
+	code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"duty.yaml", exampleConfig},
+		{"responses/foo.json", exampleFoo},
+		{"responses/created.json", exampleCreated},
+		{"responses/unauthorized.json", exampleUnauthorized},
+		{"responses/notfound.json", exampleNotFound},
+	}
+
+	if !*force {
+		for _, f := range files {
+			p := filepath.Join(*dir, filepath.FromSlash(f.name))
+
+			_, err := os.Stat(p)
+			if err == nil {
+				fmt.Fprintf(stderr, "%v already exists, use --force to overwrite\n", p)
+				return 1
+			}
+		}
+	}
+
+	for _, f := range files {
+		p := filepath.Join(*dir, filepath.FromSlash(f.name))
+
+		err := os.MkdirAll(filepath.Dir(p), 0755)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to create directory: %v\n", err.Error())
+			return 1
+		}
+
+		err = ioutil.WriteFile(p, []byte(f.content), 0644)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to write %v: %v\n", p, err.Error())
+			return 1
+		}
+
+		fmt.Fprintf(stdout, "wrote %v\n", p)
+	}
+
+	return 0
+}
@@ -1,36 +1,143 @@
 package main
 
 import (
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
+	"sort"
+)
+
+const (
+	defaultConfigFile = "./duty.yaml"
+	configFileEnv     = "DUTY_CONFIG_FILE"
+	configFormatEnv   = "DUTY_CONFIG_FORMAT"
 
-	"github.com/gomicro/duty/config"
-	log "github.com/gomicro/ledger"
+	formatUsage = "format of the config file, one of yaml, json or toml (default $" + configFormatEnv + " or the file extension)"
 )
 
+// command represents a subcommand of the duty binary, which writes its output
+// and errors to the writers it is run with
+type command struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
 var (
-	conf *config.File
+	commands map[string]command
 )
 
-func configure() {
-	c, err := config.ParseFromFile()
-	if err != nil {
-		log.Fatalf("Failed to read config file: %v", err.Error())
-		os.Exit(1)
+func init() {
+	commands = map[string]command{
+		"serve":    {"Serve the routes of a config file (default)", serve},
+		"validate": {"Validate config files and report every problem found", validate},
+		"routes":   {"Print the resolved route table of a config file", routes},
+		"record":   {"Proxy an upstream service, recording its responses as a config", record},
+		"init":     {"Scaffold an example config file with payloads", scaffold},
+		"import":   {"Convert a document such as an OpenAPI spec into a config", importDoc},
+		"export":   {"Snapshot a running duty server as a config file", export},
+		"schema":   {"Print the JSON Schema of the config", printSchema},
+		"version":  {"Print the version of duty", version},
+	}
+}
+
+func main() {
+	args := os.Args[1:]
+
+	name := "serve"
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help" || args[0] == "help") {
+		usage(os.Stderr)
+		os.Exit(0)
 	}
 
-	conf = c
-	log.Debug("Config file parsed")
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
 
-	log.Debug("Configuration complete")
+	os.Exit(cmd.run(args, os.Stdout, os.Stderr))
 }
 
-func main() {
-	configure()
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: duty [command] [flags]\n\nCommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		fmt.Fprintf(w, "  %-10v %v\n", n, commands[n].summary)
+	}
+
+	fmt.Fprintf(w, "\nRun 'duty [command] --help' for the flags of a command.\n")
+}
+
+// newFlagSet returns a flag set for the named command printing the given usage
+// line ahead of its flags to the writer when help is requested
+func newFlagSet(name, usageLine string, w io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: duty %v\n\n%v\n", usageLine, commands[name].summary)
+
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+
+		if hasFlags {
+			fmt.Fprintf(fs.Output(), "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+
+	return fs
+}
+
+// parseFlags parses the arguments of a command, returning the exit code and
+// false if the command should not run
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0, false
+	}
 
-	log.Infof("Listening on %v:%v", "0.0.0.0", "4567")
-	err := http.ListenAndServe("0.0.0.0:4567", conf)
 	if err != nil {
-		log.Errorf("server error: %v", err.Error())
+		return 2, false
 	}
+
+	return 0, true
+}
+
+// configFile returns the config file given as a flag, falling back to the file
+// set in the environment and then the default file
+func configFile(flagged string) string {
+	if flagged != "" {
+		return flagged
+	}
+
+	f := os.Getenv(configFileEnv)
+	if f == "" {
+		f = defaultConfigFile
+	}
+
+	return f
+}
+
+// configFormat returns the config format given as a flag, falling back to the
+// format set in the environment. An empty format is detected from the extension
+// of the config file.
+func configFormat(flagged string) string {
+	if flagged != "" {
+		return flagged
+	}
+
+	return os.Getenv(configFormatEnv)
 }
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/duty/config"
+	"github.com/gomicro/duty/schema"
+	. "github.com/onsi/gomega"
+)
+
+// run runs the named command with the given arguments, returning its exit code
+// along with what it wrote to stdout and stderr
+func run(name string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := commands[name].run(args, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+// chdir changes into the given directory, returning a func changing back
+func chdir(dir string) func() {
+	wd, err := os.Getwd()
+	Expect(err).To(BeNil())
+	Expect(os.Chdir(dir)).To(BeNil())
+
+	return func() {
+		Expect(os.Chdir(wd)).To(BeNil())
+	}
+}
+
+func get(url string) (int, string) {
+	res, err := http.Get(url)
+	Expect(err).To(BeNil())
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	Expect(err).To(BeNil())
+
+	return res.StatusCode, string(b)
+}
+
+func TestCommands(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Flags", func() {
+		g.It("should print the usage of a command when asked for help", func() {
+			for name := range commands {
+				code, stdout, stderr := run(name, "--help")
+				Expect(code).To(Equal(0))
+				Expect(stdout).To(Equal(""))
+				Expect(stderr).To(HavePrefix(fmt.Sprintf("Usage: duty %v", name)))
+				Expect(stderr).To(ContainSubstring(commands[name].summary))
+			}
+		})
+
+		g.It("should exit with 2 given an unknown flag", func() {
+			for name := range commands {
+				code, stdout, stderr := run(name, "--nope")
+				Expect(code).To(Equal(2))
+				Expect(stdout).To(Equal(""))
+				Expect(stderr).To(ContainSubstring("flag provided but not defined: -nope"))
+			}
+		})
+
+		g.It("should list every command in the usage", func() {
+			var b bytes.Buffer
+			usage(&b)
+
+			for name, cmd := range commands {
+				Expect(b.String()).To(ContainSubstring(fmt.Sprintf("  %-10v %v\n", name, cmd.summary)))
+			}
+		})
+	})
+
+	g.Describe("Version", func() {
+		g.It("should print the version", func() {
+			code, stdout, stderr := run("version")
+			Expect(code).To(Equal(0))
+			Expect(stdout).To(Equal(fmt.Sprintf("duty dev (%v %v/%v)\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)))
+			Expect(stderr).To(Equal(""))
+		})
+	})
+
+	g.Describe("Schema", func() {
+		g.It("should print the schema", func() {
+			code, stdout, stderr := run("schema")
+			Expect(code).To(Equal(0))
+			Expect(stdout).To(Equal(string(schema.JSON)))
+			Expect(stderr).To(Equal(""))
+		})
+	})
+
+	g.Describe("Validate", func() {
+		g.It("should report a valid config", func() {
+			code, stdout, stderr := run("validate", "./config/duty_hosts.yaml")
+			Expect(code).To(Equal(0))
+			Expect(stdout).To(Equal("./config/duty_hosts.yaml: ok\n"))
+			Expect(stderr).To(Equal(""))
+		})
+
+		g.It("should report the location of each problem", func() {
+			code, stdout, stderr := run("validate", "./config/duty_hosts.yaml", "./config/duty_invalid.yaml")
+			Expect(code).To(Equal(1))
+			Expect(stdout).To(Equal("./config/duty_hosts.yaml: ok\n"))
+
+			lines := strings.Split(strings.TrimSpace(stderr), "\n")
+			Expect(lines).To(HaveLen(8))
+			Expect(lines[0]).To(Equal(`./config/duty_invalid.yaml:7:15: routes[1].endpoint: duplicate endpoint /v1/foo, also defined by routes[0]`))
+			Expect(lines[7]).To(Equal(`./config/duty_invalid.yaml:36:10: log.level: unknown log level "verbose"`))
+		})
+
+		g.It("should report a config that cannot be read", func() {
+			code, stdout, stderr := run("validate", "./config/missing.yaml")
+			Expect(code).To(Equal(1))
+			Expect(stdout).To(Equal(""))
+			Expect(stderr).To(HavePrefix("./config/missing.yaml: "))
+		})
+	})
+
+	g.Describe("Routes", func() {
+		g.It("should list the routes of a config", func() {
+			code, stdout, stderr := run("routes", "--config", "./config/duty_hosts.yaml")
+			Expect(code).To(Equal(0))
+			Expect(stderr).To(Equal(""))
+			Expect(stdout).To(HavePrefix("CONTROL"))
+			Expect(stdout).To(ContainSubstring("HOST"))
+		})
+
+		g.It("should fail on a config that cannot be read", func() {
+			code, stdout, stderr := run("routes", "--config", "./config/missing.yaml")
+			Expect(code).To(Equal(1))
+			Expect(stdout).To(Equal(""))
+			Expect(stderr).NotTo(Equal(""))
+		})
+	})
+
+	g.Describe("Init", func() {
+		var dir string
+
+		g.BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "duty-init")
+			Expect(err).To(BeNil())
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		g.It("should scaffold a config that loads cleanly", func() {
+			code, stdout, stderr := run("init", "--dir", dir)
+			Expect(code).To(Equal(0))
+			Expect(stderr).To(Equal(""))
+			Expect(stdout).To(ContainSubstring(fmt.Sprintf("wrote %v\n", filepath.Join(dir, "duty.yaml"))))
+
+			defer chdir(dir)()
+
+			code, stdout, stderr = run("validate", "duty.yaml")
+			Expect(code).To(Equal(0))
+			Expect(stdout).To(Equal("duty.yaml: ok\n"))
+			Expect(stderr).To(Equal(""))
+		})
+
+		g.It("should refuse to overwrite a config without --force", func() {
+			code, _, _ := run("init", "--dir", dir)
+			Expect(code).To(Equal(0))
+
+			code, stdout, stderr := run("init", "--dir", dir)
+			Expect(code).To(Equal(1))
+			Expect(stdout).To(Equal(""))
+			Expect(stderr).To(ContainSubstring("already exists, use --force to overwrite"))
+
+			code, _, stderr = run("init", "--dir", dir, "--force")
+			Expect(code).To(Equal(0))
+			Expect(stderr).To(Equal(""))
+		})
+	})
+
+	g.Describe("Import", func() {
+		var dir string
+
+		g.BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "duty-import")
+			Expect(err).To(BeNil())
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		g.It("should import a document", func() {
+			spec, err := filepath.Abs("./config/petstore.yaml")
+			Expect(err).To(BeNil())
+
+			defer chdir(dir)()
+
+			code, stdout, stderr := run("import", "--from", "openapi", spec)
+			Expect(code).To(Equal(0))
+			Expect(stderr).To(Equal(""))
+			Expect(stdout).To(HavePrefix("Imported "))
+			Expect(stdout).To(HaveSuffix(" routes to ./duty.yaml\n"))
+
+			code, _, stderr = run("validate", "duty.yaml")
+			Expect(code).To(Equal(0))
+			Expect(stderr).To(Equal(""))
+		})
+
+		g.It("should require a known format and a document", func() {
+			code, stdout, stderr := run("import", "--from", "nope", "doc.json")
+			Expect(code).To(Equal(2))
+			Expect(stdout).To(Equal(""))
+			Expect(stderr).To(HavePrefix("Usage: duty import"))
+
+			code, _, _ = run("import", "--from", "openapi")
+			Expect(code).To(Equal(2))
+		})
+
+		g.It("should fail on a document that cannot be read", func() {
+			code, stdout, stderr := run("import", "--from", "har", filepath.Join(dir, "missing.har"))
+			Expect(code).To(Equal(1))
+			Expect(stdout).To(Equal(""))
+			Expect(stderr).To(HavePrefix("Failed to read document: "))
+		})
+	})
+
+	g.Describe("Export", func() {
+		var dir string
+
+		g.BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "duty-export")
+			Expect(err).To(BeNil())
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		g.It("should export the config of a running server", func() {
+			payload := filepath.Join(dir, "foo.json")
+			Expect(ioutil.WriteFile(payload, []byte(`{"foo":1}`), 0644)).To(BeNil())
+
+			f, err := config.Parse([]byte(fmt.Sprintf(`
+routes:
+  - endpoint: "/v1/foo"
+    response:
+      code: 200
+      payload: %q
+`, payload)))
+			Expect(err).To(BeNil())
+
+			server := httptest.NewServer(f)
+			defer server.Close()
+
+			out := filepath.Join(dir, "out", "duty.yaml")
+			code, stdout, stderr := run("export", "--url", server.URL, "--out", out)
+			Expect(code).To(Equal(0))
+			Expect(stderr).To(Equal(""))
+			Expect(stdout).To(Equal(fmt.Sprintf("Exported config with 1 payloads to %v\n", out)))
+
+			defer chdir(filepath.Dir(out))()
+
+			code, _, stderr = run("validate", "duty.yaml")
+			Expect(code).To(Equal(0))
+			Expect(stderr).To(Equal(""))
+		})
+
+		g.It("should fail when the server cannot be reached", func() {
+			server := httptest.NewServer(http.NotFoundHandler())
+			server.Close()
+
+			code, stdout, stderr := run("export", "--url", server.URL, "--out", filepath.Join(dir, "duty.yaml"))
+			Expect(code).To(Equal(1))
+			Expect(stdout).To(Equal(""))
+			Expect(stderr).NotTo(Equal(""))
+		})
+	})
+
+	g.Describe("Record", func() {
+		var dir string
+
+		g.BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "duty-record")
+			Expect(err).To(BeNil())
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		g.It("should require a valid target", func() {
+			code, stdout, stderr := run("record")
+			Expect(code).To(Equal(2))
+			Expect(stdout).To(Equal(""))
+			Expect(stderr).To(HavePrefix("--target is required\n"))
+
+			code, _, stderr = run("record", "--target", "localhost")
+			Expect(code).To(Equal(2))
+			Expect(stderr).To(Equal("invalid target url: localhost\n"))
+		})
+
+		g.It("should record responses that are served back the same", func() {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, `{"path":%q}`, req.URL.Path)
+			}))
+			defer upstream.Close()
+
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).To(BeNil())
+			addr := l.Addr().String()
+			l.Close()
+
+			out := filepath.Join(dir, "duty.yaml")
+			done := make(chan int)
+			go func() {
+				code, _, _ := run("record", "--target", upstream.URL, "--addr", addr, "--out", out)
+				done <- code
+			}()
+
+			var res *http.Response
+			for i := 0; i < 50; i++ {
+				res, err = http.Get("http://" + addr + "/v1/things")
+				if err == nil {
+					break
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+			Expect(err).To(BeNil())
+			res.Body.Close()
+
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGINT)).To(BeNil())
+
+			select {
+			case code := <-done:
+				Expect(code).To(Equal(0))
+			case <-time.After(5 * time.Second):
+				g.Fail("record did not stop once interrupted")
+			}
+
+			defer chdir(dir)()
+
+			f, err := config.ParseFile("duty.yaml")
+			Expect(err).To(BeNil())
+
+			server := httptest.NewServer(f)
+			defer server.Close()
+
+			code, body := get(server.URL + "/v1/things")
+			Expect(code).To(Equal(http.StatusCreated))
+			Expect(body).To(Equal(`{"path":"/v1/things"}`))
+		})
+	})
+}
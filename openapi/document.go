@@ -0,0 +1,440 @@
+// Package openapi reads OpenAPI 3 documents, providing what duty needs of them
+// to generate example responses and to validate the requests it receives.
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	maxRefHops = 16
+)
+
+var (
+	// Methods are the methods an operation may be defined for, in the order
+	// they are listed in a path item
+	Methods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+)
+
+// Document represents the parts of an OpenAPI 3 document describing its
+// operations
+type Document struct {
+	OpenAPI    string               `yaml:"openapi"`
+	Servers    []Server             `yaml:"servers"`
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components Components           `yaml:"components"`
+}
+
+// Server represents a server the operations of a document are served from
+type Server struct {
+	URL       string `yaml:"url"`
+	Variables map[string]struct {
+		Default string `yaml:"default"`
+	} `yaml:"variables"`
+}
+
+// Components represents the reusable objects of a document that may be
+// referenced from its operations
+type Components struct {
+	Schemas       map[string]*Schema      `yaml:"schemas"`
+	Responses     map[string]*Response    `yaml:"responses"`
+	Parameters    map[string]*Parameter   `yaml:"parameters"`
+	Examples      map[string]*Example     `yaml:"examples"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies"`
+	Headers       map[string]*Header      `yaml:"headers"`
+}
+
+// PathItem represents the operations available on a path
+type PathItem struct {
+	Get        *Operation   `yaml:"get"`
+	Put        *Operation   `yaml:"put"`
+	Post       *Operation   `yaml:"post"`
+	Delete     *Operation   `yaml:"delete"`
+	Options    *Operation   `yaml:"options"`
+	Head       *Operation   `yaml:"head"`
+	Patch      *Operation   `yaml:"patch"`
+	Trace      *Operation   `yaml:"trace"`
+	Parameters []*Parameter `yaml:"parameters"`
+}
+
+// Operation represents a single method on a path
+type Operation struct {
+	OperationID string               `yaml:"operationId"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
+	Responses   map[string]*Response `yaml:"responses"`
+	Method      string               `yaml:"-"`
+	Path        string               `yaml:"-"`
+	pathParams  []*Parameter
+	pattern     *regexp.Regexp
+	paramNames  []string
+}
+
+// Parameter represents a path, query or header parameter of an operation
+type Parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *Schema `yaml:"schema"`
+}
+
+// RequestBody represents the body an operation accepts
+type RequestBody struct {
+	Ref      string                `yaml:"$ref"`
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+}
+
+// Response represents a documented response of an operation
+type Response struct {
+	Ref     string                `yaml:"$ref"`
+	Headers map[string]*Header    `yaml:"headers"`
+	Content map[string]*MediaType `yaml:"content"`
+}
+
+// Header represents a documented header of a response
+type Header struct {
+	Ref     string      `yaml:"$ref"`
+	Schema  *Schema     `yaml:"schema"`
+	Example interface{} `yaml:"example"`
+}
+
+// MediaType represents the schema and examples of a body of a given content
+// type
+type MediaType struct {
+	Schema   *Schema             `yaml:"schema"`
+	Example  interface{}         `yaml:"example"`
+	Examples map[string]*Example `yaml:"examples"`
+}
+
+// Example represents a named example value
+type Example struct {
+	Ref   string      `yaml:"$ref"`
+	Value interface{} `yaml:"value"`
+}
+
+// Parse reads an OpenAPI 3 document written in YAML or JSON
+func Parse(b []byte) (*Document, error) {
+	var doc Document
+	err := yaml.Unmarshal(b, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal OpenAPI document: %v", err.Error())
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", doc.OpenAPI)
+	}
+
+	base := doc.BasePath()
+
+	for p, item := range doc.Paths {
+		if item == nil {
+			continue
+		}
+
+		pattern, names, err := pathPattern(base + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %v: %v", p, err.Error())
+		}
+
+		for _, m := range Methods {
+			op := item.Operation(m)
+			if op == nil {
+				continue
+			}
+
+			op.Method = m
+			op.Path = p
+			op.pathParams = item.Parameters
+			op.pattern = pattern
+			op.paramNames = names
+		}
+	}
+
+	return &doc, nil
+}
+
+// Operation returns the operation of the path item for the method, if it has
+// one
+func (item *PathItem) Operation(method string) *Operation {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return item.Get
+	case "PUT":
+		return item.Put
+	case "POST":
+		return item.Post
+	case "DELETE":
+		return item.Delete
+	case "OPTIONS":
+		return item.Options
+	case "HEAD":
+		return item.Head
+	case "PATCH":
+		return item.Patch
+	case "TRACE":
+		return item.Trace
+	}
+
+	return nil
+}
+
+// SortedPaths returns the paths of the document in order
+func (d *Document) SortedPaths() []string {
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+// BasePath returns the path of the first server of the document, with its
+// variables replaced by their defaults
+func (d *Document) BasePath() string {
+	if len(d.Servers) == 0 {
+		return ""
+	}
+
+	s := d.Servers[0]
+	raw := s.URL
+	for name, v := range s.Variables {
+		raw = strings.Replace(raw, "{"+name+"}", v.Default, -1)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimRight(u.Path, "/")
+}
+
+// OperationByID returns the operation with the given operation ID
+func (d *Document) OperationByID(id string) (*Operation, bool) {
+	for _, p := range d.SortedPaths() {
+		item := d.Paths[p]
+		if item == nil {
+			continue
+		}
+
+		for _, m := range Methods {
+			op := item.Operation(m)
+			if op != nil && op.OperationID == id {
+				return op, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// FindOperation returns the operation documented for the method and request
+// path, beneath the base path of the document, along with the values of its
+// path parameters. Paths with more literal segments are preferred when several
+// match.
+func (d *Document) FindOperation(method, path string) (*Operation, map[string]string, bool) {
+	var (
+		match    *Operation
+		params   map[string]string
+		literals int
+	)
+
+	for _, p := range d.SortedPaths() {
+		item := d.Paths[p]
+		if item == nil {
+			continue
+		}
+
+		op := item.Operation(method)
+		if op == nil {
+			continue
+		}
+
+		values, ok := op.MatchPath(path)
+		if !ok {
+			continue
+		}
+
+		n := literalSegments(p)
+		if match == nil || n > literals {
+			match, params, literals = op, values, n
+		}
+	}
+
+	return match, params, match != nil
+}
+
+// MatchPath matches the request path against the path of the operation beneath
+// the base path of its document, returning the values of its path parameters
+func (op *Operation) MatchPath(path string) (map[string]string, bool) {
+	if op.pattern == nil {
+		return nil, false
+	}
+
+	m := op.pattern.FindStringSubmatch(path)
+	if m == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, name := range op.paramNames {
+		params[name] = m[i+1]
+	}
+
+	return params, true
+}
+
+// pathPattern compiles a path template, whose parameters may share a segment
+// with literal text such as `{name}.{ext}`, into a pattern matching request
+// paths and the names of the parameters it captures
+func pathPattern(path string) (*regexp.Regexp, []string, error) {
+	var (
+		b     strings.Builder
+		names []string
+	)
+
+	b.WriteString("^")
+
+	for path != "" {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			b.WriteString(regexp.QuoteMeta(path))
+			break
+		}
+
+		b.WriteString(regexp.QuoteMeta(path[:start]))
+		b.WriteString("([^/]+?)")
+
+		names = append(names, path[start+1:end])
+		path = path[end+1:]
+	}
+
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return re, names, nil
+}
+
+func literalSegments(path string) int {
+	n := 0
+	for _, seg := range strings.Split(path, "/") {
+		if !strings.Contains(seg, "{") {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Params returns the parameters of the operation, including those defined on
+// its path that it does not override
+func (d *Document) Params(op *Operation) []*Parameter {
+	var params []*Parameter
+	seen := make(map[string]bool)
+
+	for _, p := range op.Parameters {
+		p = d.ResolveParameter(p)
+		if p == nil {
+			continue
+		}
+
+		seen[p.In+":"+p.Name] = true
+		params = append(params, p)
+	}
+
+	for _, p := range op.pathParams {
+		p = d.ResolveParameter(p)
+		if p == nil || seen[p.In+":"+p.Name] {
+			continue
+		}
+
+		params = append(params, p)
+	}
+
+	return params
+}
+
+// ResolveSchema follows any references of the schema to the schema they
+// refer to
+func (d *Document) ResolveSchema(s *Schema) *Schema {
+	for i := 0; s != nil && s.Ref != "" && i < maxRefHops; i++ {
+		s = d.Components.Schemas[refName(s.Ref, "schemas")]
+	}
+
+	return s
+}
+
+// ResolveResponse follows any references of the response to the response
+// they refer to
+func (d *Document) ResolveResponse(r *Response) *Response {
+	for i := 0; r != nil && r.Ref != "" && i < maxRefHops; i++ {
+		r = d.Components.Responses[refName(r.Ref, "responses")]
+	}
+
+	return r
+}
+
+// ResolveParameter follows any references of the parameter to the parameter
+// they refer to
+func (d *Document) ResolveParameter(p *Parameter) *Parameter {
+	for i := 0; p != nil && p.Ref != "" && i < maxRefHops; i++ {
+		p = d.Components.Parameters[refName(p.Ref, "parameters")]
+	}
+
+	return p
+}
+
+// ResolveRequestBody follows any references of the request body to the
+// request body they refer to
+func (d *Document) ResolveRequestBody(b *RequestBody) *RequestBody {
+	for i := 0; b != nil && b.Ref != "" && i < maxRefHops; i++ {
+		b = d.Components.RequestBodies[refName(b.Ref, "requestBodies")]
+	}
+
+	return b
+}
+
+// ResolveExample follows any references of the example to the example they
+// refer to
+func (d *Document) ResolveExample(e *Example) *Example {
+	for i := 0; e != nil && e.Ref != "" && i < maxRefHops; i++ {
+		e = d.Components.Examples[refName(e.Ref, "examples")]
+	}
+
+	return e
+}
+
+// ResolveHeader follows any references of the header to the header they refer
+// to
+func (d *Document) ResolveHeader(h *Header) *Header {
+	for i := 0; h != nil && h.Ref != "" && i < maxRefHops; i++ {
+		h = d.Components.Headers[refName(h.Ref, "headers")]
+	}
+
+	return h
+}
+
+// refName returns the name of a local reference to a component of the given
+// kind, or an empty name for any other reference
+func refName(ref, kind string) string {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return ""
+	}
+
+	return strings.TrimPrefix(ref, prefix)
+}
@@ -0,0 +1,160 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+const doc = `
+openapi: "3.0.3"
+servers:
+  - url: "https://{host}/{version}"
+    variables:
+      host:
+        default: "api.example.test"
+      version:
+        default: "v1"
+paths:
+  /files/{name}.{ext}:
+    get:
+      operationId: getFile
+  /files/{id}:
+    get:
+      operationId: getFileByID
+  /files/latest:
+    get:
+      operationId: getLatest
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        tags:
+          type: array
+          maxItems: 1
+          items:
+            type: string
+    Node:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        child:
+          $ref: "#/components/schemas/Node"
+    Size:
+      enum: [1, 2.5, "3", true]
+    Loop:
+      allOf:
+        - $ref: "#/components/schemas/Loop"
+        - type: string
+`
+
+func TestDocument(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Documents", func() {
+		var d *Document
+
+		g.Before(func() {
+			var err error
+			d, err = Parse([]byte(doc))
+			Expect(err).To(BeNil())
+		})
+
+		g.It("should refuse documents that are not OpenAPI 3", func() {
+			_, err := Parse([]byte(`swagger: "2.0"`))
+			Expect(err).NotTo(BeNil())
+		})
+
+		g.It("should take the base path from the first server", func() {
+			Expect(d.BasePath()).To(Equal("/v1"))
+		})
+
+		g.It("should find operations by path, preferring literal segments", func() {
+			op, params, found := d.FindOperation("GET", "/v1/files/latest")
+			Expect(found).To(BeTrue())
+			Expect(op.OperationID).To(Equal("getLatest"))
+			Expect(params).To(BeEmpty())
+
+			op, params, found = d.FindOperation("GET", "/v1/files/42")
+			Expect(found).To(BeTrue())
+			Expect(op.OperationID).To(Equal("getFileByID"))
+			Expect(params["id"]).To(Equal("42"))
+
+			_, _, found = d.FindOperation("POST", "/v1/files/42")
+			Expect(found).To(BeFalse())
+		})
+
+		g.It("should match parameters sharing a segment", func() {
+			op, found := d.OperationByID("getFile")
+			Expect(found).To(BeTrue())
+
+			params, ok := op.MatchPath("/v1/files/report.pdf")
+			Expect(ok).To(BeTrue())
+			Expect(params["name"]).To(Equal("report"))
+			Expect(params["ext"]).To(Equal("pdf"))
+		})
+
+		g.It("should validate values against referenced schemas", func() {
+			s := &Schema{Ref: "#/components/schemas/Pet"}
+
+			vs := d.ValidateValue(s, map[string]interface{}{"name": "Rex"}, "body", "")
+			Expect(vs).To(BeEmpty())
+
+			vs = d.ValidateValue(s, map[string]interface{}{"tags": []interface{}{"a", 1.0}}, "body", "")
+			Expect(vs).To(HaveLen(3))
+			Expect(vs[0].String()).To(Equal("body name is required"))
+			Expect(vs[1].String()).To(Equal("body tags must have at most 1 items"))
+			Expect(vs[2].String()).To(Equal("body tags[1] must be of type string"))
+		})
+
+		g.It("should validate values nested however deep in recursive schemas", func() {
+			s := &Schema{Ref: "#/components/schemas/Node"}
+
+			v := map[string]interface{}{}
+			name := "child"
+			for i := 0; i < 12; i++ {
+				v = map[string]interface{}{"name": "n", "child": v}
+				name = "child." + name
+			}
+
+			vs := d.ValidateValue(s, map[string]interface{}{"name": "root", "child": v}, "body", "")
+			Expect(vs).To(HaveLen(1))
+			Expect(vs[0].String()).To(Equal("body " + name + ".name is required"))
+		})
+
+		g.It("should validate values against schemas combining themselves", func() {
+			s := &Schema{Ref: "#/components/schemas/Loop"}
+
+			Expect(d.ValidateValue(s, "loop", "body", "")).To(BeEmpty())
+			Expect(d.ValidateValue(s, 1.0, "body", "")).To(HaveLen(1))
+		})
+
+		g.It("should only accept values of enums of the same type", func() {
+			s := &Schema{Ref: "#/components/schemas/Size"}
+
+			for _, v := range []interface{}{1.0, 2.5, "3", true} {
+				Expect(d.ValidateValue(s, v, "query", "size")).To(BeEmpty())
+			}
+
+			for _, v := range []interface{}{"1", "2.5", 3.0, "true", nil} {
+				vs := d.ValidateValue(s, v, "query", "size")
+				Expect(vs).To(HaveLen(1))
+				Expect(vs[0].String()).To(Equal("query size must be one of 1, 2.5, 3, true"))
+			}
+		})
+
+		g.It("should sample recursive schemas without recurring", func() {
+			s := &Schema{Ref: "#/components/schemas/Node"}
+
+			Expect(d.Sample(s)).To(Equal(map[string]interface{}{"name": "string", "child": nil}))
+		})
+	})
+}
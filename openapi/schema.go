@@ -0,0 +1,442 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema represents the constraints on a value described by a document
+type Schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 interface{}        `yaml:"type"`
+	Format               string             `yaml:"format"`
+	Nullable             bool               `yaml:"nullable"`
+	Properties           map[string]*Schema `yaml:"properties"`
+	Required             []string           `yaml:"required"`
+	AdditionalProperties *Additional        `yaml:"additionalProperties"`
+	Items                *Schema            `yaml:"items"`
+	MinItems             *int               `yaml:"minItems"`
+	MaxItems             *int               `yaml:"maxItems"`
+	MinLength            *int               `yaml:"minLength"`
+	MaxLength            *int               `yaml:"maxLength"`
+	Pattern              string             `yaml:"pattern"`
+	Minimum              *float64           `yaml:"minimum"`
+	Maximum              *float64           `yaml:"maximum"`
+	Example              interface{}        `yaml:"example"`
+	Default              interface{}        `yaml:"default"`
+	Enum                 []interface{}      `yaml:"enum"`
+	AllOf                []*Schema          `yaml:"allOf"`
+	OneOf                []*Schema          `yaml:"oneOf"`
+	AnyOf                []*Schema          `yaml:"anyOf"`
+}
+
+// Additional represents whether an object allows properties beyond those it
+// lists, and the schema they must satisfy when given
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalYAML reads additional properties given either as a boolean or as a
+// schema
+func (a *Additional) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		return n.Decode(&a.Allowed)
+	}
+
+	a.Allowed = true
+	a.Schema = &Schema{}
+
+	return n.Decode(a.Schema)
+}
+
+// Types returns the types the schema allows, and whether it allows null
+func (s *Schema) Types() ([]string, bool) {
+	var types []string
+	nullable := s.Nullable
+
+	switch t := s.Type.(type) {
+	case string:
+		types = append(types, t)
+
+	case []interface{}:
+		for _, v := range t {
+			str, ok := v.(string)
+			if !ok {
+				continue
+			}
+
+			if str == "null" {
+				nullable = true
+				continue
+			}
+
+			types = append(types, str)
+		}
+	}
+
+	if len(types) == 0 && s.Properties != nil {
+		types = append(types, "object")
+	}
+
+	return types, nullable
+}
+
+// Sample generates an example value satisfying the schema. A schema nested
+// within itself, through its references, is sampled as null where it recurs.
+func (d *Document) Sample(s *Schema) interface{} {
+	return d.sample(s, map[*Schema]bool{})
+}
+
+// sample generates a value for the schema, where the schemas being sampled
+// are marked as seen so that one recurring within itself is not sampled again
+func (d *Document) sample(s *Schema, seen map[*Schema]bool) interface{} {
+	s = d.ResolveSchema(s)
+	if s == nil || seen[s] {
+		return nil
+	}
+
+	seen[s] = true
+	defer delete(seen, s)
+
+	switch {
+	case s.Example != nil:
+		return s.Example
+
+	case s.Default != nil:
+		return s.Default
+
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+
+	case len(s.AllOf) > 0:
+		merged := map[string]interface{}{}
+		for _, sub := range s.AllOf {
+			m, ok := d.sample(sub, seen).(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			for k, v := range m {
+				merged[k] = v
+			}
+		}
+
+		return merged
+
+	case len(s.OneOf) > 0:
+		return d.sample(s.OneOf[0], seen)
+
+	case len(s.AnyOf) > 0:
+		return d.sample(s.AnyOf[0], seen)
+	}
+
+	types, _ := s.Types()
+	if len(types) == 0 {
+		return nil
+	}
+
+	switch types[0] {
+	case "object":
+		m := map[string]interface{}{}
+		for k, p := range s.Properties {
+			m[k] = d.sample(p, seen)
+		}
+
+		return m
+
+	case "array":
+		if s.Items == nil {
+			return []interface{}{}
+		}
+
+		return []interface{}{d.sample(s.Items, seen)}
+
+	case "string":
+		return sampleString(s.Format)
+
+	case "integer":
+		return 0
+
+	case "number":
+		return 0.0
+
+	case "boolean":
+		return true
+	}
+
+	return nil
+}
+
+func sampleString(format string) string {
+	switch format {
+	case "date":
+		return "1970-01-01"
+	case "date-time":
+		return "1970-01-01T00:00:00Z"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "email":
+		return "user@example.test"
+	case "uri", "url":
+		return "http://example.test"
+	case "hostname":
+		return "example.test"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	}
+
+	return "string"
+}
+
+// ValidateValue checks the value, as decoded from JSON, against the schema,
+// returning a violation for each constraint it breaks. Violations are named by
+// the path of the offending value beneath the given name.
+func (d *Document) ValidateValue(s *Schema, v interface{}, in, name string) []Violation {
+	var vs []Violation
+	d.validate(s, v, in, name, map[*Schema]bool{}, &vs)
+
+	return vs
+}
+
+// validate checks the value against the schema, where the schemas the value is
+// being checked against are marked as seen so that one combining itself, as by
+// allOf, is not checked again. Values within the value are checked afresh.
+func (d *Document) validate(s *Schema, v interface{}, in, name string, seen map[*Schema]bool, vs *[]Violation) {
+	s = d.ResolveSchema(s)
+	if s == nil || seen[s] {
+		return
+	}
+
+	seen[s] = true
+	defer delete(seen, s)
+
+	add := func(format string, args ...interface{}) {
+		*vs = append(*vs, Violation{In: in, Name: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, sub := range s.AllOf {
+		d.validate(sub, v, in, name, seen, vs)
+	}
+
+	if len(s.OneOf) > 0 && d.matching(s.OneOf, v, seen) != 1 {
+		add("must match exactly one schema of oneOf")
+	}
+
+	if len(s.AnyOf) > 0 && d.matching(s.AnyOf, v, seen) == 0 {
+		add("must match at least one schema of anyOf")
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		add("must be one of %v", formatEnum(s.Enum))
+	}
+
+	types, nullable := s.Types()
+
+	if v == nil {
+		if !nullable && len(types) > 0 {
+			add("must not be null")
+		}
+
+		return
+	}
+
+	if len(types) > 0 && !hasType(types, v) {
+		add("must be of type %v", strings.Join(types, " or "))
+		return
+	}
+
+	switch t := v.(type) {
+	case string:
+		n := len([]rune(t))
+		if s.MinLength != nil && n < *s.MinLength {
+			add("must be at least %v characters", *s.MinLength)
+		}
+
+		if s.MaxLength != nil && n > *s.MaxLength {
+			add("must be at most %v characters", *s.MaxLength)
+		}
+
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err == nil && !re.MatchString(t) {
+				add("must match pattern %v", s.Pattern)
+			}
+		}
+
+	case float64:
+		if s.Minimum != nil && t < *s.Minimum {
+			add("must be at least %v", *s.Minimum)
+		}
+
+		if s.Maximum != nil && t > *s.Maximum {
+			add("must be at most %v", *s.Maximum)
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(t) < *s.MinItems {
+			add("must have at least %v items", *s.MinItems)
+		}
+
+		if s.MaxItems != nil && len(t) > *s.MaxItems {
+			add("must have at most %v items", *s.MaxItems)
+		}
+
+		if s.Items != nil {
+			for i, item := range t {
+				d.validate(s.Items, item, in, fmt.Sprintf("%v[%v]", name, i), map[*Schema]bool{}, vs)
+			}
+		}
+
+	case map[string]interface{}:
+		for _, req := range s.Required {
+			if _, ok := t[req]; !ok {
+				*vs = append(*vs, Violation{In: in, Name: join(name, req), Message: "is required"})
+			}
+		}
+
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			p, listed := s.Properties[k]
+			if listed {
+				d.validate(p, t[k], in, join(name, k), map[*Schema]bool{}, vs)
+				continue
+			}
+
+			if s.AdditionalProperties == nil {
+				continue
+			}
+
+			if !s.AdditionalProperties.Allowed {
+				*vs = append(*vs, Violation{In: in, Name: join(name, k), Message: "is not an allowed property"})
+				continue
+			}
+
+			if s.AdditionalProperties.Schema != nil {
+				d.validate(s.AdditionalProperties.Schema, t[k], in, join(name, k), map[*Schema]bool{}, vs)
+			}
+		}
+	}
+}
+
+// matching counts the schemas the value satisfies
+func (d *Document) matching(schemas []*Schema, v interface{}, seen map[*Schema]bool) int {
+	n := 0
+	for _, sub := range schemas {
+		var vs []Violation
+		d.validate(sub, v, "", "", seen, &vs)
+
+		if len(vs) == 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+func hasType(types []string, v interface{}) bool {
+	for _, t := range types {
+		switch val := v.(type) {
+		case string:
+			if t == "string" {
+				return true
+			}
+
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+
+		case float64:
+			if t == "number" || (t == "integer" && val == math.Trunc(val)) {
+				return true
+			}
+
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	v = normalize(v)
+	for _, e := range enum {
+		if reflect.DeepEqual(normalize(e), v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalize returns the value with its numbers as float64, as they are decoded
+// from JSON, so that values read from YAML may be compared with it
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	case float32:
+		return float64(t)
+
+	case []interface{}:
+		items := make([]interface{}, len(t))
+		for i, item := range t {
+			items[i] = normalize(item)
+		}
+
+		return items
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[k] = normalize(val)
+		}
+
+		return m
+	}
+
+	return v
+}
+
+func formatEnum(enum []interface{}) string {
+	vals := make([]string, len(enum))
+	for i, e := range enum {
+		vals[i] = fmt.Sprintf("%v", e)
+	}
+
+	return strings.Join(vals, ", ")
+}
+
+func join(name, key string) string {
+	if name == "" {
+		return key
+	}
+
+	return name + "." + key
+}
@@ -0,0 +1,210 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Violation represents a part of a request that does not satisfy the operation
+// it was validated against
+type Violation struct {
+	In      string `json:"in"`
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Name == "" {
+		return fmt.Sprintf("%v %v", v.In, v.Message)
+	}
+
+	return fmt.Sprintf("%v %v %v", v.In, v.Name, v.Message)
+}
+
+// ValidateRequest checks the path, query and header parameters of the request,
+// and its body, against the operation. A violation is returned for each
+// constraint of the operation the request breaks.
+func (d *Document) ValidateRequest(op *Operation, req *http.Request, body []byte) []Violation {
+	var vs []Violation
+
+	pathValues, matched := op.MatchPath(req.URL.Path)
+	if !matched {
+		vs = append(vs, Violation{In: "path", Message: fmt.Sprintf("does not match %v", d.BasePath()+op.Path)})
+	}
+
+	query := req.URL.Query()
+
+	for _, p := range d.Params(op) {
+		var values []string
+
+		switch p.In {
+		case "path":
+			if !matched {
+				continue
+			}
+
+			v, ok := pathValues[p.Name]
+			if ok {
+				values = []string{v}
+			}
+
+		case "query":
+			values = query[p.Name]
+
+		case "header":
+			values = req.Header.Values(p.Name)
+
+		case "cookie":
+			c, err := req.Cookie(p.Name)
+			if err == nil {
+				values = []string{c.Value}
+			}
+
+		default:
+			continue
+		}
+
+		if len(values) == 0 {
+			if p.Required || p.In == "path" {
+				vs = append(vs, Violation{In: p.In, Name: p.Name, Message: "is required"})
+			}
+
+			continue
+		}
+
+		if p.Schema == nil {
+			continue
+		}
+
+		v := d.coerce(p.Schema, values)
+		vs = append(vs, d.ValidateValue(p.Schema, v, p.In, p.Name)...)
+	}
+
+	return append(vs, d.validateBody(op, req, body)...)
+}
+
+func (d *Document) validateBody(op *Operation, req *http.Request, body []byte) []Violation {
+	rb := d.ResolveRequestBody(op.RequestBody)
+	if rb == nil {
+		return nil
+	}
+
+	if len(body) == 0 {
+		if rb.Required {
+			return []Violation{{In: "body", Message: "is required"}}
+		}
+
+		return nil
+	}
+
+	ct, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		ct = ""
+	}
+
+	mt, found := mediaType(rb.Content, ct)
+	if !found {
+		return []Violation{{In: "header", Name: "Content-Type", Message: fmt.Sprintf("must be one of %v", strings.Join(contentTypes(rb.Content), ", "))}}
+	}
+
+	if mt == nil || mt.Schema == nil || !IsJSON(ct) {
+		return nil
+	}
+
+	var v interface{}
+	err = json.Unmarshal(body, &v)
+	if err != nil {
+		return []Violation{{In: "body", Message: fmt.Sprintf("is not valid JSON: %v", err.Error())}}
+	}
+
+	return d.ValidateValue(mt.Schema, v, "body", "")
+}
+
+// mediaType returns the media type of the content matching the content type,
+// including by wildcards such as `application/*`
+func mediaType(content map[string]*MediaType, ct string) (*MediaType, bool) {
+	if mt, ok := content[ct]; ok {
+		return mt, true
+	}
+
+	if i := strings.Index(ct, "/"); i > 0 {
+		if mt, ok := content[ct[:i]+"/*"]; ok {
+			return mt, true
+		}
+	}
+
+	mt, ok := content["*/*"]
+
+	return mt, ok
+}
+
+func contentTypes(content map[string]*MediaType) []string {
+	types := make([]string, 0, len(content))
+	for ct := range content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// IsJSON reports whether the media type is JSON
+func IsJSON(mediaType string) bool {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return false
+	}
+
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// coerce converts the raw values of a parameter into the type its schema
+// expects, leaving values that do not convert as strings to be reported
+func (d *Document) coerce(s *Schema, values []string) interface{} {
+	s = d.ResolveSchema(s)
+
+	var types []string
+	if s != nil {
+		types, _ = s.Types()
+	}
+
+	if len(types) == 0 {
+		return values[0]
+	}
+
+	if types[0] == "array" {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+
+		items := make([]interface{}, len(values))
+		for i, v := range values {
+			items[i] = d.coerce(s.Items, []string{v})
+		}
+
+		return items
+	}
+
+	v := values[0]
+
+	switch types[0] {
+	case "integer", "number":
+		f, err := strconv.ParseFloat(v, 64)
+		if err == nil {
+			return f
+		}
+
+	case "boolean":
+		b, err := strconv.ParseBool(v)
+		if err == nil {
+			return b
+		}
+	}
+
+	return v
+}
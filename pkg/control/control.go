@@ -0,0 +1,55 @@
+// Package control defines the errors the control endpoints of duty report and
+// the codes they are reported with, shared by the config package serving the
+// endpoints and the client calling them.
+package control
+
+import (
+	"errors"
+)
+
+// ErrorCodeHeader is the response header the control endpoints report the
+// code of a known error in
+const ErrorCodeHeader = "Duty-Error-Code"
+
+var (
+	// ErrRouteNotFound is returned when setting a route that does not exist
+	ErrRouteNotFound = errors.New("no route found")
+
+	// ErrInvalidRouteType is returned when setting the response of a route
+	// that is not a variable route
+	ErrInvalidRouteType = errors.New("invalid route type")
+
+	// ErrIDNotFound is returned when setting a route to a response id it does
+	// not have
+	ErrIDNotFound = errors.New("ID not found")
+)
+
+var codes = map[error]string{
+	ErrRouteNotFound:    "route_not_found",
+	ErrInvalidRouteType: "invalid_route_type",
+	ErrIDNotFound:       "id_not_found",
+}
+
+// Code returns the code the error is reported with, or an empty string if it is
+// not a known error
+func Code(err error) string {
+	for known, code := range codes {
+		if errors.Is(err, known) {
+			return code
+		}
+	}
+
+	return ""
+}
+
+// FromCode returns the known error reported with the code, or nil if there is
+// none
+func FromCode(code string) error {
+	for known, c := range codes {
+		if c == code {
+			return known
+		}
+	}
+
+	return nil
+}
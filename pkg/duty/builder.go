@@ -0,0 +1,342 @@
+package duty
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gomicro/duty/config"
+)
+
+// FileBuilder constructs a duty config from routes built in Go rather than
+// read from YAML
+type FileBuilder struct {
+	hosts    []config.Host
+	notFound *config.Response
+	cors     *config.CORS
+	routes   []*RouteBuilder
+	errs     []error
+}
+
+// RouteBuilder constructs a single route of a duty config
+type RouteBuilder struct {
+	route     config.Route
+	responses []*ResponseBuilder
+}
+
+// ResponseBuilder constructs a single response of a route
+type ResponseBuilder struct {
+	response config.Response
+	err      error
+}
+
+// Config returns a builder for a duty config serving the given routes
+func Config(routes ...*RouteBuilder) *FileBuilder {
+	return &FileBuilder{
+		routes: routes,
+	}
+}
+
+// Route adds routes to the config
+func (b *FileBuilder) Route(routes ...*RouteBuilder) *FileBuilder {
+	b.routes = append(b.routes, routes...)
+	return b
+}
+
+// Host adds the options for a host, such as its not found response, to the
+// config
+func (b *FileBuilder) Host(host string, notFound *ResponseBuilder) *FileBuilder {
+	h := config.Host{Host: host}
+
+	if notFound != nil {
+		resp, err := notFound.Build()
+		if err != nil {
+			b.errs = append(b.errs, fmt.Errorf("host %v: %v", host, err.Error()))
+		}
+
+		h.NotFound = resp
+	}
+
+	b.hosts = append(b.hosts, h)
+	return b
+}
+
+// NotFound sets the response served when no route matches a request
+func (b *FileBuilder) NotFound(resp *ResponseBuilder) *FileBuilder {
+	r, err := resp.Build()
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("not found: %v", err.Error()))
+	}
+
+	b.notFound = &r
+	return b
+}
+
+// CORS sets the CORS policy applied to every route without its own policy
+func (b *FileBuilder) CORS(c config.CORS) *FileBuilder {
+	b.cors = &c
+	return b
+}
+
+// Build validates the routes and returns the initialized config ready to be
+// served. All of the problems found with the routes are returned together, and
+// once they build, the config is validated as a config file would be.
+func (b *FileBuilder) Build() (*config.File, error) {
+	errs := append([]error{}, b.errs...)
+	seen := make(map[string]bool)
+
+	f := &config.File{
+		Hosts:    b.hosts,
+		NotFound: b.notFound,
+		CORS:     b.cors,
+	}
+
+	for _, rb := range b.routes {
+		r, err := rb.Build()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		key := strings.ToLower(r.Host) + r.Endpoint
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("route %v: duplicate endpoint", r.Host+r.Endpoint))
+			continue
+		}
+		seen[key] = true
+
+		f.Routes = append(f.Routes, r)
+	}
+
+	if len(errs) > 0 {
+		return nil, joinErrors(errs)
+	}
+
+	problems := f.Validate()
+	if len(problems) > 0 {
+		return nil, &config.ValidationError{Problems: problems}
+	}
+
+	err := f.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Serve builds the config and starts a test server for it, failing the test if
+// the config is invalid.
+func (b *FileBuilder) Serve(t testing.TB) *Server {
+	t.Helper()
+
+	f, err := b.Build()
+	if err != nil {
+		t.Fatalf("invalid duty config: %v", err.Error())
+	}
+
+	return NewTestServer(t, f)
+}
+
+// Route returns a builder for a route serving the given endpoint
+func Route(endpoint string) *RouteBuilder {
+	return &RouteBuilder{
+		route: config.Route{
+			Endpoint: endpoint,
+		},
+	}
+}
+
+// Host scopes the route to requests for the given host
+func (b *RouteBuilder) Host(host string) *RouteBuilder {
+	b.route.Host = host
+	return b
+}
+
+// Name sets the name the route is referred to by when setting its response
+func (b *RouteBuilder) Name(name string) *RouteBuilder {
+	b.route.Name = name
+	return b
+}
+
+// CORS sets the CORS policy of the route
+func (b *RouteBuilder) CORS(c config.CORS) *RouteBuilder {
+	b.route.CORS = &c
+	return b
+}
+
+// Static sets the route to always return the given response
+func (b *RouteBuilder) Static(resp *ResponseBuilder) *RouteBuilder {
+	b.route.Type = config.StaticRouteType
+	b.responses = []*ResponseBuilder{resp}
+	return b
+}
+
+// Ordinal sets the route to return the given responses in order, repeating the
+// last once reached
+func (b *RouteBuilder) Ordinal(resps ...*ResponseBuilder) *RouteBuilder {
+	b.route.Type = config.OrdinalRouteType
+	b.responses = resps
+	return b
+}
+
+// Variable sets the route to return the first of the given responses until
+// another is selected by its id
+func (b *RouteBuilder) Variable(resps ...*ResponseBuilder) *RouteBuilder {
+	b.route.Type = config.VariableRouteType
+	b.responses = resps
+	return b
+}
+
+// Verb sets the route to return the response matching the method of the
+// request
+func (b *RouteBuilder) Verb(resps ...*ResponseBuilder) *RouteBuilder {
+	b.route.Type = config.VerbRouteType
+	b.responses = resps
+	return b
+}
+
+// Build validates and returns the route
+func (b *RouteBuilder) Build() (config.Route, error) {
+	var errs []error
+
+	r := b.route
+	name := fmt.Sprintf("route %v%v", r.Host, r.Endpoint)
+
+	if !strings.HasPrefix(r.Endpoint, "/") && r.Endpoint != "*" {
+		errs = append(errs, fmt.Errorf("%v: endpoint must begin with a slash", name))
+	}
+
+	if len(b.responses) == 0 {
+		errs = append(errs, fmt.Errorf("%v: no responses specified", name))
+	}
+
+	ids := make(map[string]bool)
+	for i, rb := range b.responses {
+		resp, err := rb.Build()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: response %v: %v", name, i, err.Error()))
+			continue
+		}
+
+		switch r.Type {
+		case config.VariableRouteType:
+			if resp.ID == "" {
+				errs = append(errs, fmt.Errorf("%v: response %v: id is required for variable routes", name, i))
+			} else if ids[resp.ID] {
+				errs = append(errs, fmt.Errorf("%v: response %v: duplicate id %v", name, i, resp.ID))
+			}
+
+			ids[resp.ID] = true
+
+		case config.VerbRouteType:
+			if resp.Verb == "" {
+				errs = append(errs, fmt.Errorf("%v: response %v: verb is required for verb routes", name, i))
+			}
+		}
+
+		r.Responses = append(r.Responses, resp)
+	}
+
+	if r.Type == config.VariableRouteType && r.Name == "" {
+		errs = append(errs, fmt.Errorf("%v: name is required for variable routes", name))
+	}
+
+	if r.Type == config.StaticRouteType && len(r.Responses) > 0 {
+		r.Response = r.Responses[0]
+		r.Responses = nil
+	}
+
+	if len(errs) > 0 {
+		return config.Route{}, joinErrors(errs)
+	}
+
+	return r, nil
+}
+
+// Respond returns a builder for a response with the given status code
+func Respond(code int) *ResponseBuilder {
+	return &ResponseBuilder{
+		response: config.Response{
+			Code: code,
+		},
+	}
+}
+
+// ID sets the id the response is selected by on variable routes
+func (b *ResponseBuilder) ID(id string) *ResponseBuilder {
+	b.response.ID = id
+	return b
+}
+
+// Verb sets the method the response is returned for on verb routes
+func (b *ResponseBuilder) Verb(verb string) *ResponseBuilder {
+	b.response.Verb = strings.ToUpper(verb)
+	return b
+}
+
+// Header sets a header on the response
+func (b *ResponseBuilder) Header(key, value string) *ResponseBuilder {
+	if b.response.Headers == nil {
+		b.response.Headers = make(map[string]string)
+	}
+
+	b.response.Headers[key] = value
+	return b
+}
+
+// Body sets the body of the response
+func (b *ResponseBuilder) Body(body string) *ResponseBuilder {
+	b.response.Body = body
+	return b
+}
+
+// JSON sets the body of the response to the given value marshaled as JSON, and
+// the content type accordingly
+func (b *ResponseBuilder) JSON(v interface{}) *ResponseBuilder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		b.err = fmt.Errorf("failed to marshal json body: %v", err.Error())
+		return b
+	}
+
+	b.response.Body = string(body)
+	return b.Header("Content-Type", "application/json")
+}
+
+// Payload sets the body of the response to the contents of the file at the
+// given path
+func (b *ResponseBuilder) Payload(path string) *ResponseBuilder {
+	b.response.Payload = path
+	return b
+}
+
+// Template sets the body of the response to the given template, rendered
+// against the request being responded to
+func (b *ResponseBuilder) Template(tmpl string) *ResponseBuilder {
+	b.response.Template = tmpl
+	return b
+}
+
+// Build validates and returns the response
+func (b *ResponseBuilder) Build() (config.Response, error) {
+	if b.err != nil {
+		return config.Response{}, b.err
+	}
+
+	if b.response.Code < 100 || b.response.Code > 599 {
+		return config.Response{}, fmt.Errorf("invalid status code %v", b.response.Code)
+	}
+
+	return b.response, nil
+}
+
+func joinErrors(errs []error) error {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Errorf("%v", strings.Join(msgs, "; "))
+}
@@ -0,0 +1,114 @@
+package duty
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/duty/config"
+	. "github.com/onsi/gomega"
+)
+
+func TestBuilder(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Builder", func() {
+		g.It("should serve built routes", func() {
+			s := Config(
+				Route("/v1/foo").Ordinal(
+					Respond(200).JSON(map[string]string{"foo": "bar"}),
+					Respond(503),
+				),
+				Route("/v1/verb").Verb(
+					Respond(200).Verb("get").Body("got"),
+					Respond(201).Verb("post"),
+				),
+			).Serve(t)
+
+			res, err := http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/foo"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(string(b)).To(Equal(`{"foo":"bar"}`))
+
+			res, err = http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/foo"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+
+			res, err = http.Post(fmt.Sprintf("%v%v", s.URL, "/v1/verb"), "text/plain", nil)
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusCreated))
+		})
+
+		g.It("should set built variable routes", func() {
+			s := Config(
+				Route("/v1/var").Name("var").Variable(
+					Respond(200).ID("ok"),
+					Respond(500).ID("broken"),
+				),
+			).Serve(t)
+
+			Expect(s.Set("var", "broken")).To(BeNil())
+
+			res, err := http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/var"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusInternalServerError))
+		})
+
+		g.It("should report every problem with the routes", func() {
+			_, err := Config(
+				Route("v1/foo").Static(Respond(0)),
+				Route("/v1/var").Variable(Respond(200), Respond(200).ID("a"), Respond(200).ID("a")),
+				Route("/v1/verb").Verb(Respond(200)),
+				Route("/v1/ordinal").Ordinal(),
+				Route("/v1/json").Static(Respond(200).JSON(func() {})),
+				Route("/v1/dup").Static(Respond(200)),
+				Route("/v1/dup").Static(Respond(200)),
+			).Build()
+			Expect(err).NotTo(BeNil())
+
+			Expect(err.Error()).To(ContainSubstring("route v1/foo: endpoint must begin with a slash"))
+			Expect(err.Error()).To(ContainSubstring("route v1/foo: response 0: invalid status code 0"))
+			Expect(err.Error()).To(ContainSubstring("route /v1/var: response 0: id is required for variable routes"))
+			Expect(err.Error()).To(ContainSubstring("route /v1/var: response 2: duplicate id a"))
+			Expect(err.Error()).To(ContainSubstring("route /v1/var: name is required for variable routes"))
+			Expect(err.Error()).To(ContainSubstring("route /v1/verb: response 0: verb is required for verb routes"))
+			Expect(err.Error()).To(ContainSubstring("route /v1/ordinal: no responses specified"))
+			Expect(err.Error()).To(ContainSubstring("route /v1/json: response 0: failed to marshal json body"))
+			Expect(err.Error()).To(ContainSubstring("route /v1/dup: duplicate endpoint"))
+		})
+
+		g.It("should report duplicate routes on hosts differing in case", func() {
+			_, err := Config(
+				Route("/v1/dup").Host("api.example.test").Static(Respond(200)),
+				Route("/v1/dup").Host("API.Example.test").Static(Respond(200)),
+			).Build()
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("route API.Example.test/v1/dup: duplicate endpoint"))
+		})
+
+		g.It("should report the problems the config is validated for", func() {
+			_, err := Config(
+				Route("/v1/items/{id}").Name("items").Static(Respond(200)),
+				Route("/v1/items/{sku}").Name("items").Static(Respond(200)),
+			).Build()
+			Expect(err).NotTo(BeNil())
+
+			var verr *config.ValidationError
+			Expect(errors.As(err, &verr)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("routes[1].endpoint: duplicate endpoint /v1/items/{sku}, also defined by routes[0]"))
+			Expect(err.Error()).To(ContainSubstring("routes[1].name: duplicate route name items, also used by routes[0]"))
+		})
+	})
+}
@@ -0,0 +1,92 @@
+// Package duty provides the mocked http responses of Duty in process, so Go
+// tests can stand up a duty server without running the duty container. The
+// routes of a server are read from a config file or a YAML string, with the
+// same semantics as the duty binary.
+package duty
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gomicro/duty/config"
+)
+
+// Server represents a running duty server listening on a local port
+type Server struct {
+	*httptest.Server
+	File *config.File
+}
+
+// FromFile returns the duty config read from the file at the given path
+func FromFile(path string) (*config.File, error) {
+	return config.ParseFile(path)
+}
+
+// FromYAML returns the duty config parsed from the given YAML string
+func FromYAML(y string) (*config.File, error) {
+	return config.Parse([]byte(y))
+}
+
+// NewServer starts and returns a server serving the given config. The caller
+// should call Close when finished to shut it down.
+func NewServer(f *config.File) *Server {
+	return &Server{
+		Server: httptest.NewServer(f),
+		File:   f,
+	}
+}
+
+// NewTestServer starts and returns a server serving the given config, which is
+// closed automatically when the test and its subtests complete.
+func NewTestServer(t testing.TB, f *config.File) *Server {
+	t.Helper()
+
+	s := NewServer(f)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// NewTestServerFromFile starts a test server serving the config file at the
+// given path, failing the test if the file can not be read.
+func NewTestServerFromFile(t testing.TB, path string) *Server {
+	t.Helper()
+
+	f, err := FromFile(path)
+	if err != nil {
+		t.Fatalf("failed to read duty config: %v", err.Error())
+	}
+
+	return NewTestServer(t, f)
+}
+
+// NewTestServerFromYAML starts a test server serving the given YAML config,
+// failing the test if it can not be parsed.
+func NewTestServerFromYAML(t testing.TB, y string) *Server {
+	t.Helper()
+
+	f, err := FromYAML(y)
+	if err != nil {
+		t.Fatalf("failed to parse duty config: %v", err.Error())
+	}
+
+	return NewTestServer(t, f)
+}
+
+// Close shuts the server down and closes the access log of its config
+func (s *Server) Close() {
+	s.Server.Close()
+	s.File.Close() //nolint:errcheck
+}
+
+// Reset returns every route of the server to the response it starts at, given
+// by its start or selected responses, or otherwise its first response
+func (s *Server) Reset() {
+	s.File.ResetRoutes()
+}
+
+// Set sets the named route of the server to return the response with the
+// given id.
+func (s *Server) Set(name, id string) error {
+	return s.File.SetRoute(name, id)
+}
@@ -0,0 +1,92 @@
+package duty
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+const testConfig = `
+routes:
+  - endpoint: "/v1/ordinal"
+    type: "ordinal"
+    responses:
+      - code: 200
+        template: "first"
+      - code: 503
+        template: "second"
+
+  - endpoint: "/v1/variable"
+    type: "variable"
+    name: "var"
+    responses:
+      - code: 200
+        id: "ok"
+      - code: 404
+        id: "missing"
+`
+
+func TestDuty(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Duty", func() {
+		g.It("should serve a config from a YAML string", func() {
+			s := NewTestServerFromYAML(t, testConfig)
+
+			res, err := http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/ordinal"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(string(b)).To(Equal("first"))
+
+			res, err = http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/ordinal"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+
+			s.Reset()
+
+			res, err = http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/ordinal"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		g.It("should set a variable route", func() {
+			s := NewTestServerFromYAML(t, testConfig)
+
+			err := s.Set("var", "missing")
+			Expect(err).To(BeNil())
+
+			res, err := http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/variable"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+
+			err = s.Set("nope", "missing")
+			Expect(err).NotTo(BeNil())
+		})
+
+		g.It("should serve a config from a file", func() {
+			s := NewTestServerFromFile(t, "../../config/duty_other.yaml")
+			Expect(len(s.File.Routes)).To(Equal(2))
+
+			res, err := http.Get(fmt.Sprintf("%v%v", s.URL, "/v1/missing"))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+}
@@ -0,0 +1,163 @@
+package duty
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gomicro/duty/config"
+)
+
+var (
+	// ErrAborted is returned by the transport when the handler aborts the
+	// connection, such as when a fault is injected into the response
+	ErrAborted = errors.New("duty aborted the connection")
+)
+
+// Transport is an http.RoundTripper that serves requests with a duty config in
+// process, without opening any sockets. Faults that drop the connection, such
+// as an sse route disconnecting after its events, surface as ErrAborted, either
+// from RoundTrip or from reading the body. Faults that answer with a well formed
+// but wrong response, such as ignored or misreported ranges, are returned as
+// that response, just as they are over the network. Websocket routes can not be
+// reached through the transport, as its responses can not be hijacked to
+// upgrade the connection.
+type Transport struct {
+	Handler http.Handler
+}
+
+// NewTransport returns a transport serving requests with the given config
+func NewTransport(f *config.File) *Transport {
+	return &Transport{
+		Handler: f,
+	}
+}
+
+// Client returns an http client using the transport
+func (t *Transport) Client() *http.Client {
+	return &http.Client{
+		Transport: t,
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface, returning the response
+// of the handler once its headers are written. The body is streamed to the
+// response as the handler writes it.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sreq := req.Clone(req.Context())
+	sreq.RequestURI = req.URL.RequestURI()
+	sreq.RemoteAddr = "127.0.0.1:0"
+
+	if sreq.Host == "" {
+		sreq.Host = req.URL.Host
+	}
+
+	if sreq.Body == nil {
+		sreq.Body = http.NoBody
+	}
+
+	pr, pw := io.Pipe()
+	w := &pipeWriter{
+		header: http.Header{},
+		pipe:   pw,
+		head:   req.Method == http.MethodHead,
+		ready:  make(chan struct{}),
+	}
+
+	errc := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			r := recover()
+			if r == nil {
+				w.WriteHeader(http.StatusOK)
+				pw.Close()
+				return
+			}
+
+			err := ErrAborted
+			if r != http.ErrAbortHandler {
+				err = fmt.Errorf("duty handler panicked: %v", r)
+			}
+
+			if w.wroteHeader {
+				pw.CloseWithError(err)
+				return
+			}
+
+			errc <- err
+		}()
+
+		t.Handler.ServeHTTP(w, sreq)
+	}()
+
+	select {
+	case <-w.ready:
+		return &http.Response{
+			Status:        fmt.Sprintf("%v %v", w.code, http.StatusText(w.code)),
+			StatusCode:    w.code,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        w.sent,
+			Body:          pr,
+			ContentLength: -1,
+			Request:       req,
+		}, nil
+
+	case err := <-errc:
+		return nil, err
+
+	case <-req.Context().Done():
+		pr.Close()
+		return nil, req.Context().Err()
+	}
+}
+
+// pipeWriter is a response writer streaming the body written by a handler to
+// the response returned by the transport
+type pipeWriter struct {
+	header      http.Header
+	sent        http.Header
+	pipe        *io.PipeWriter
+	head        bool
+	code        int
+	wroteHeader bool
+	ready       chan struct{}
+}
+
+func (w *pipeWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *pipeWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.code = code
+	w.sent = w.header.Clone()
+	close(w.ready)
+}
+
+func (w *pipeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.header.Get("Content-Type") == "" {
+			w.header.Set("Content-Type", http.DetectContentType(b))
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.head {
+		return len(b), nil
+	}
+
+	return w.pipe.Write(b)
+}
+
+// Flush implements the http.Flusher interface. Writes are passed to the
+// response body as they are made, so there is nothing to flush.
+func (w *pipeWriter) Flush() {}
@@ -0,0 +1,122 @@
+package duty
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestTransport(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Transport", func() {
+		g.It("should serve requests from a config without a server", func() {
+			f, err := Config(
+				Route("/v1/foo").Ordinal(
+					Respond(200).Body("first"),
+					Respond(503),
+				),
+			).Build()
+			Expect(err).To(BeNil())
+
+			c := NewTransport(f).Client()
+
+			res, err := c.Get("http://api.example.test/v1/foo")
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(string(b)).To(Equal("first"))
+
+			res, err = c.Get("http://api.example.test/v1/foo")
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+
+			res, err = c.Get("http://api.example.test/v1/missing")
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		g.It("should surface the faults of a config dropping the connection", func() {
+			f, err := FromYAML(`
+routes:
+  - endpoint: "/v1/events"
+    type: "sse"
+    repeat: true
+    disconnectAfter: 3
+    events:
+      - data: "tick"
+`)
+			Expect(err).To(BeNil())
+
+			res, err := NewTransport(f).Client().Get("http://api.example.test/v1/events")
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			b, err := ioutil.ReadAll(res.Body)
+			Expect(errors.Is(err, ErrAborted)).To(BeTrue())
+			Expect(strings.Count(string(b), "data: tick")).To(Equal(3))
+		})
+
+		g.It("should return the responses of faults answering wrongly", func() {
+			f, err := FromYAML(`
+routes:
+  - endpoint: "/v1/download"
+    response:
+      code: 200
+      payload: "../../config/foo.json"
+      ranges: "misreport"
+`)
+			Expect(err).To(BeNil())
+
+			req, err := http.NewRequest("GET", "http://api.example.test/v1/download", nil)
+			Expect(err).To(BeNil())
+			req.Header.Set("Range", "bytes=0-3")
+
+			res, err := NewTransport(f).Client().Do(req)
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusPartialContent))
+			Expect(res.Header.Get("Content-Range")).To(HavePrefix("bytes 1-4/"))
+		})
+
+		g.It("should surface aborted connections as transport errors", func() {
+			c := (&Transport{
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					panic(http.ErrAbortHandler)
+				}),
+			}).Client()
+
+			_, err := c.Get("http://api.example.test/v1/foo")
+			Expect(errors.Is(err, ErrAborted)).To(BeTrue())
+		})
+
+		g.It("should surface connections aborted mid body as read errors", func() {
+			c := (&Transport{
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte("partial")) //nolint:errcheck
+					panic(http.ErrAbortHandler)
+				}),
+			}).Client()
+
+			res, err := c.Get("http://api.example.test/v1/foo")
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			_, err = ioutil.ReadAll(res.Body)
+			Expect(errors.Is(err, ErrAborted)).To(BeTrue())
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gomicro/duty/generate"
+	log "github.com/gomicro/ledger"
+)
+
+// record proxies requests to an upstream service until interrupted, then
+// writes the responses it returned as a config file and payloads
+func record(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("record", "record --target <url> [flags]", stderr)
+	target := fs.String("target", "", "url of the upstream service to record (required)")
+	addr := fs.String("addr", defaultAddr, "address to listen on")
+	out := fs.String("out", defaultConfigFile, "config file to write once interrupted")
+	payloads := fs.String("payloads", "responses", "directory, relative to the config file, to write payloads into")
+
+	code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	if *target == "" {
+		fmt.Fprintln(stderr, "--target is required")
+		fs.Usage()
+		return 2
+	}
+
+	u, err := url.Parse(*target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		fmt.Fprintf(stderr, "invalid target url: %v\n", *target)
+		return 2
+	}
+
+	rec := generate.NewRecorder(u)
+	server := &http.Server{Addr: *addr, Handler: rec}
+
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(done)
+
+	go func() {
+		<-done
+		server.Close()
+	}()
+
+	log.Infof("Recording %v on %v", u, *addr)
+	err = server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		log.Errorf("server error: %v", err.Error())
+		return 1
+	}
+
+	f, p := generate.FromExchanges(rec.Exchanges(), *payloads)
+
+	err = generate.Write(f, p, *out)
+	if err != nil {
+		log.Errorf("%v", err.Error())
+		return 1
+	}
+
+	log.Infof("Recorded %v routes to %v", len(f.Routes), *out)
+
+	return 0
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gomicro/duty/config"
+)
+
+func routes(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("routes", "routes [flags]", stderr)
+	file := fs.String("config", "", "config file to read (default $"+configFileEnv+" or "+defaultConfigFile+")")
+	format := fs.String("format", "", formatUsage)
+
+	code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	f, err := config.ParseFileFormat(configFile(*file), configFormat(*format))
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "CONTROL\tENDPOINT\n")
+	fmt.Fprintf(w, "status\t%v\n", f.Status)
+	fmt.Fprintf(w, "reset\t%v\n", f.Reset)
+	fmt.Fprintf(w, "set\t%v\n", f.Set)
+	fmt.Fprintf(w, "metrics\t%v\n", f.Metrics)
+	fmt.Fprintf(w, "export\t%v\n", f.Export)
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "HOST\tENDPOINT\tTYPE\tNAME\tRESPONSES\tSOURCE\n")
+	for _, r := range f.Routes {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			orDash(r.Host),
+			r.Endpoint,
+			routeType(r),
+			orDash(r.Name),
+			describeResponses(r),
+			orDash(r.Source),
+		)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return 1
+	}
+
+	return 0
+}
+
+func routeType(r config.Route) string {
+	if r.Type == "" {
+		return config.StaticRouteType
+	}
+
+	return strings.ToLower(r.Type)
+}
+
+func describeResponses(r config.Route) string {
+	switch routeType(r) {
+	case config.SSERouteType:
+		return fmt.Sprintf("%v events", len(r.Events))
+
+	case config.WebSocketRouteType:
+		if r.Socket == nil {
+			return "-"
+		}
+
+		return fmt.Sprintf("%v frames on connect, %v replies", len(r.Socket.OnConnect), len(r.Socket.Replies))
+	}
+
+	resps := r.Responses
+	if routeType(r) == config.StaticRouteType {
+		resps = []config.Response{r.Response}
+	}
+
+	descs := make([]string, len(resps))
+	for i, resp := range resps {
+		var parts []string
+
+		if resp.Verb != "" {
+			parts = append(parts, strings.ToUpper(resp.Verb))
+		}
+
+		if resp.ID != "" {
+			parts = append(parts, "id="+resp.ID)
+		}
+
+		if resp.GRPC != nil {
+			parts = append(parts, describeGRPC(resp.GRPC))
+			descs[i] = strings.Join(parts, " ")
+			continue
+		}
+
+		parts = append(parts, fmt.Sprintf("%v", resp.Code))
+
+		switch {
+		case resp.Template != "":
+			parts = append(parts, "(template)")
+		case resp.Body != "":
+			parts = append(parts, "(body)")
+		case resp.Payload != "":
+			parts = append(parts, resp.Payload)
+		}
+
+		descs[i] = strings.Join(parts, " ")
+	}
+
+	return strings.Join(descs, ", ")
+}
+
+func describeGRPC(g *config.GRPCResponse) string {
+	status := g.Status
+	if status == "" {
+		status = "OK"
+	}
+
+	return fmt.Sprintf("grpc %v (%v messages)", strings.ToUpper(status), len(g.Messages))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"io"
+
+	"github.com/gomicro/duty/schema"
+)
+
+func printSchema(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("schema", "schema", stderr)
+
+	code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	_, err := stdout.Write(schema.JSON)
+	if err != nil {
+		return 1
+	}
+
+	return 0
+}
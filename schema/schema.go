@@ -0,0 +1,12 @@
+// Package schema publishes the JSON Schema of the duty config, allowing editors
+// to validate and complete configs written in YAML, JSON or TOML.
+package schema
+
+import (
+	_ "embed" // embeds the schema document
+)
+
+// JSON is the JSON Schema document describing a duty config
+//
+//go:embed duty.schema.json
+var JSON []byte
@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/duty/config"
+	. "github.com/onsi/gomega"
+)
+
+type definition struct {
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type document struct {
+	definition
+	Definitions map[string]definition `json:"definitions"`
+}
+
+func TestSchema(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Schema", func() {
+		var doc document
+
+		g.It("should be a valid JSON document", func() {
+			Expect(json.Unmarshal(JSON, &doc)).To(BeNil())
+		})
+
+		types := map[string]interface{}{
+			"":             config.File{},
+			"route":        config.Route{},
+			"response":     config.Response{},
+			"host":         config.Host{},
+			"cors":         config.CORS{},
+			"logging":      config.Logging{},
+			"accessLog":    config.AccessLog{},
+			"openapi":      config.OpenAPI{},
+			"grpc":         config.GRPC{},
+			"grpcResponse": config.GRPCResponse{},
+			"stream":       config.Stream{},
+			"event":        config.Event{},
+			"socket":       config.Socket{},
+			"frame":        config.Frame{},
+			"reply":        config.Reply{},
+			"closing":      config.Closing{},
+		}
+
+		for name, v := range types {
+			name, v := name, v
+
+			g.It("should describe every option of "+reflect.TypeOf(v).Name(), func() {
+				def := doc.definition
+				if name != "" {
+					def = doc.Definitions[name]
+				}
+
+				for _, opt := range options(v) {
+					Expect(def.Properties).To(HaveKey(opt))
+				}
+
+				Expect(def.Properties).To(HaveLen(len(options(v))))
+			})
+		}
+	})
+}
+
+// options returns the names of the yaml options of the struct
+func options(v interface{}) []string {
+	var opts []string
+
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("yaml")
+		name := strings.Split(tag, ",")[0]
+
+		if name == "" || name == "-" {
+			continue
+		}
+
+		opts = append(opts, name)
+	}
+
+	return opts
+}
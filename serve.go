@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/gomicro/duty/config"
+	log "github.com/gomicro/ledger"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const (
+	defaultAddr = "0.0.0.0:4567"
+)
+
+var (
+	conf *config.File
+)
+
+func configure(file, format string) {
+	c, err := config.ParseFileFormat(file, format)
+	if err != nil {
+		log.Fatalf("Failed to read config file: %v", err.Error())
+		os.Exit(1)
+	}
+
+	conf = c
+	log.Debug("Config file parsed")
+
+	log.Debug("Configuration complete")
+}
+
+func serve(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("serve", "serve [flags]", stderr)
+	file := fs.String("config", "", "config file to serve (default $"+configFileEnv+" or "+defaultConfigFile+")")
+	format := fs.String("format", "", formatUsage)
+	addr := fs.String("addr", defaultAddr, "address to listen on")
+	cert := fs.String("tls-cert", "", "certificate file to serve TLS with")
+	key := fs.String("tls-key", "", "private key file of the TLS certificate")
+
+	code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	configure(configFile(*file), configFormat(*format))
+	defer conf.Close() //nolint:errcheck
+
+	if (*cert == "") != (*key == "") {
+		fmt.Fprintln(stderr, "--tls-cert and --tls-key must be given together")
+		fs.Usage()
+		return 2
+	}
+
+	log.Infof("Listening on %v", *addr)
+
+	var err error
+	if *cert != "" {
+		err = http.ListenAndServeTLS(*addr, *cert, *key, conf)
+	} else {
+		// HTTP/2 without TLS is served to clients asking for it, as gRPC
+		// clients do, while every other request is served over HTTP/1.1
+		err = http.ListenAndServe(*addr, h2c.NewHandler(conf, &http2.Server{}))
+	}
+
+	if err != nil {
+		log.Errorf("server error: %v", err.Error())
+		return 1
+	}
+
+	return 0
+}
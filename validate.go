@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gomicro/duty/config"
+)
+
+// validate checks each of the given config files, or the configured file if
+// none are given, printing every problem found. A non-zero exit code is
+// returned if any file is invalid.
+func validate(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("validate", "validate [flags] [config files...]", stderr)
+	format := fs.String("format", "", formatUsage)
+
+	code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{configFile("")}
+	}
+
+	for _, f := range files {
+		err := config.ValidateFileFormat(f, configFormat(*format))
+		if err == nil {
+			fmt.Fprintf(stdout, "%v: ok\n", f)
+			continue
+		}
+
+		code = 1
+
+		var verr *config.ValidationError
+		if !errors.As(err, &verr) {
+			fmt.Fprintf(stderr, "%v: %v\n", f, err.Error())
+			continue
+		}
+
+		for _, p := range verr.Problems {
+			fmt.Fprintln(stderr, p.String())
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+var (
+	// buildVersion is set at build time from the BUILD_VERSION of the Makefile,
+	// or the VERSION of a release
+	buildVersion = "dev"
+)
+
+func version(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("version", "version", stderr)
+
+	code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	fmt.Fprintf(stdout, "duty %v (%v %v/%v)\n", buildVersion, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	return 0
+}